@@ -16,6 +16,19 @@
 // Can be represented in steps as:
 //
 //	[match, match, match, match, match, deletion, match, insertion]
+//
+// Format renders steps as above, and Summarize counts identities, gaps etc.
+//
+// # Gap Scores
+//
+// Gaps are scored with an affine scheme. A gap of length k that consists of
+// characters c1...ck scores:
+//
+//	m[Gap,Gap] + m[c1,Gap] + ... + m[ck,Gap]
+//
+// (or m[Gap,c1]... for gaps in a). That is, the [Gap,Gap] entry is the cost of
+// opening a gap, and the character entries are the costs of extending it.
+// WithGaps sets these scores for an existing matrix.
 package align
 
 import (
@@ -88,12 +101,31 @@ func (m SubstitutionMatrix) Symmetrical() SubstitutionMatrix {
 	return result
 }
 
-// A single element in the dynamic-programming table of the alignment algorithm.
+// A score of an alignment, and the last step that achieves it.
 type block struct {
 	score float64
 	step  Step
 }
 
+// WithGaps returns a copy of m with the given gap scores. open is the score of
+// opening a gap, and extend is the score of each gapped character. For example,
+// a gap of length 3 scores open+3*extend. Scores are typically negative.
+func (m SubstitutionMatrix) WithGaps(open, extend float64) SubstitutionMatrix {
+	result := SubstitutionMatrix{}
+	for k, v := range m {
+		if k[0] == Gap || k[1] == Gap {
+			continue
+		}
+		result[k] = v
+		result[[2]byte{k[0], Gap}] = extend
+		result[[2]byte{Gap, k[0]}] = extend
+		result[[2]byte{k[1], Gap}] = extend
+		result[[2]byte{Gap, k[1]}] = extend
+	}
+	result[[2]byte{Gap, Gap}] = open
+	return result
+}
+
 // GoString implements the fmt.GoStringer interface.
 func (m SubstitutionMatrix) GoString() string {
 	buf := &strings.Builder{}
@@ -150,3 +182,26 @@ func (s *substitutionArray) get(a, b byte) float64 {
 	}
 	return x
 }
+
+// Returns the score of the given alignment of a and b.
+func (s *substitutionArray) score(a, b []byte, steps []Step) float64 {
+	open := s.get(Gap, Gap)
+	score := 0.0
+	for i, step := range steps {
+		if step != Match && (i == 0 || steps[i-1] != step) {
+			score += open
+		}
+		switch step {
+		case Match:
+			score += s.get(a[0], b[0])
+			a, b = a[1:], b[1:]
+		case Deletion:
+			score += s.get(a[0], Gap)
+			a = a[1:]
+		case Insertion:
+			score += s.get(Gap, b[0])
+			b = b[1:]
+		}
+	}
+	return score
+}
@@ -22,32 +22,37 @@ func TestDecideOnStep(t *testing.T) {
 	}
 }
 
-func TestTraceAlignmentSteps(t *testing.T) {
+func TestTraceSteps(t *testing.T) {
 	tests := []struct {
-		blocks    []block
+		trace     []cellSteps
 		bn        int
+		i         int
+		last      Step
 		want      []Step
-		wantScore float64
+		wantStart int
 	}{
-		{[]block{{}, {}, {}, {score: 5, step: Match}},
-			2, []Step{Match}, 5},
-		{[]block{{}, {}, {}, {}, {step: Match}, {}, {}, {}, {score: 10, step: Match}},
-			3, []Step{Match, Match}, 10},
-		{[]block{{}, {step: Insertion}, {}, {}, {},
-			{step: Match}, {}, {}, {score: 8, step: Deletion}},
-			3, []Step{Insertion, Match, Deletion}, 8},
-		{[]block{{}, {}, {},
-			{step: Deletion}, {}, {},
-			{step: Deletion}, {step: Insertion}, {score: 7, step: Insertion}},
-			3, []Step{Deletion, Deletion, Insertion, Insertion}, 7},
+		{[]cellSteps{{}, {}, {}, {Match: Match}},
+			2, 3, Match, []Step{Match}, 0},
+		{[]cellSteps{{}, {}, {}, {}, {Match: Match}, {}, {}, {}, {Match: Match}},
+			3, 8, Match, []Step{Match, Match}, 0},
+		{[]cellSteps{{}, {Insertion: Match}, {}, {}, {},
+			{Match: Insertion}, {}, {}, {Deletion: Match}},
+			3, 8, Deletion, []Step{Insertion, Match, Deletion}, 0},
+		{[]cellSteps{{}, {}, {},
+			{Deletion: Match}, {}, {},
+			{Deletion: Deletion}, {Insertion: Deletion}, {Insertion: Insertion}},
+			3, 8, Insertion, []Step{Deletion, Deletion, Insertion, Insertion}, 0},
+		{[]cellSteps{{}, {}, {}, {}, {}, {}, {}, {}, {Match: 0}},
+			3, 8, Match, []Step{Match}, 4},
 	}
 	for _, test := range tests {
-		got, gotScore := traceAlignmentSteps(test.blocks, test.bn)
+		got, gotStart := traceSteps(test.trace, test.bn, test.i, test.last)
 		if !reflect.DeepEqual(got, test.want) {
-			t.Errorf("traceAlignmentSteps(...)=%v, want %v", got, test.want)
+			t.Errorf("traceSteps(...)=%v, want %v", got, test.want)
 		}
-		if gotScore != test.wantScore {
-			t.Errorf("traceAlignmentSteps(...)= score=%v, want %v", gotScore, test.wantScore)
+		if gotStart != test.wantStart {
+			t.Errorf("traceSteps(...) start=%v, want %v",
+				gotStart, test.wantStart)
 		}
 	}
 }
@@ -106,3 +111,24 @@ func TestGoString(t *testing.T) {
 		t.Fatalf("%v.GoString=%q, want %q", input, got, want)
 	}
 }
+
+func TestWithGaps(t *testing.T) {
+	input := SubstitutionMatrix{
+		{'a', 'a'}: 1,
+		{'a', 'b'}: 2,
+		{'a', Gap}: 3,
+		{Gap, Gap}: 4,
+	}
+	want := SubstitutionMatrix{
+		{'a', 'a'}: 1,
+		{'a', 'b'}: 2,
+		{'a', Gap}: -1,
+		{'b', Gap}: -1,
+		{Gap, 'a'}: -1,
+		{Gap, 'b'}: -1,
+		{Gap, Gap}: -5,
+	}
+	if got := input.WithGaps(-5, -1); !reflect.DeepEqual(got, want) {
+		t.Fatalf("%v.WithGaps(-5,-1)=%v, want %v", input, got, want)
+	}
+}
@@ -0,0 +1,144 @@
+// Banded alignment.
+
+package align
+
+import "fmt"
+
+// GlobalBanded performs global alignment on a and b and finds the highest
+// scoring alignment, considering only the diagonals d-w through d+w of the
+// dynamic-programming table. Diagonal k is where the position in b minus the
+// position in a equals k, so d=0 is the main diagonal. Returns the steps relating
+// to a, and the alignment score. edge is true if the alignment touches the edge
+// of the band, in which case a higher scoring alignment may exist outside the
+// band.
+// Time and space complexities are O(len(a)*w).
+//
+// The band must contain the start and the end of both sequences, otherwise
+// GlobalBanded panics.
+func GlobalBanded(a, b []byte, m SubstitutionMatrix, w, d int) (
+	steps []Step, score float64, edge bool) {
+	if w < 0 {
+		panic(fmt.Sprintf("bad band width: %v", w))
+	}
+	if d < -w || d > w {
+		panic(fmt.Sprintf("band (w=%v, d=%v) does not contain the start "+
+			"of the sequences", w, d))
+	}
+	if k := len(b) - len(a); k < d-w || k > d+w {
+		panic(fmt.Sprintf("band (w=%v, d=%v) does not contain the end "+
+			"of the sequences (%v)", w, d, k))
+	}
+	trace, end, last, score := gotohBanded(a, b, m.toArray(), w, d, false)
+	steps, _ = traceSteps(trace, 2*w, end, last)
+	return steps, score, touchesBand(steps, 0, 0, len(a), len(b), w, d)
+}
+
+// LocalBanded performs local alignment on a and b and finds the highest scoring
+// alignment, considering only the diagonals d-w through d+w of the
+// dynamic-programming table, as in GlobalBanded. Returns the steps relating to a,
+// ai and bi as the start positions of the local alignment in a and b
+// respectively, and the alignment score. edge is true if the alignment touches
+// the edge of the band, in which case a higher scoring alignment may exist
+// outside the band.
+// Time and space complexities are O(len(a)*w).
+func LocalBanded(a, b []byte, m SubstitutionMatrix, w, d int) (
+	steps []Step, ai, bi int, score float64, edge bool) {
+	if w < 0 {
+		panic(fmt.Sprintf("bad band width: %v", w))
+	}
+	bw := 2*w + 1
+	trace, end, last, score := gotohBanded(a, b, m.toArray(), w, d, true)
+	if score <= 0 {
+		return nil, -1, -1, 0, false
+	}
+	steps, start := traceSteps(trace, bw-1, end, last)
+	ai = start / bw
+	bi = ai + d + start%bw - w
+	edge = touchesBand(steps, ai, bi, len(a), len(b), w, d)
+	return steps, ai, bi, score, edge
+}
+
+// Same as gotoh, but fills only the cells in the band of diagonals d-w through
+// d+w. Cell (ai,bi) is at index ai*(2w+1)+(bi-ai-d+w) of the returned pointers.
+// Moving a row down along a diagonal therefore adds 2w+1 to the index,
+// so the pointers can be traced with traceSteps, using 2w as the row length.
+func gotohBanded(a, b []byte, s *substitutionArray, w, d int, local bool) (
+	trace []cellSteps, end int, last Step, score float64) {
+	an, bw := len(a)+1, 2*w+1
+	open := s.get(Gap, Gap)
+	trace = make([]cellSteps, an*bw)
+	prev, cur := make([]cellScores, bw), make([]cellScores, bw)
+	for k := range bw {
+		prev[k] = cellScores{negInf, negInf, negInf, negInf}
+	}
+	score = negInf
+
+	for ai := range an {
+		for k := range bw {
+			i := ai*bw + k
+			bi := ai + d + k - w
+			cur[k] = cellScores{negInf, negInf, negInf, negInf}
+			if bi < 0 || bi > len(b) {
+				continue
+			}
+
+			if ai > 0 && bi > 0 {
+				blk := enterCell(prev[k], Match, open,
+					local || ai == 1 && bi == 1)
+				cur[k][Match] = blk.score + s.get(a[ai-1], b[bi-1])
+				trace[i][Match] = blk.step
+			}
+			if ai > 0 && k < bw-1 {
+				blk := enterCell(prev[k+1], Deletion, open,
+					!local && ai == 1 && bi == 0)
+				cur[k][Deletion] = blk.score + s.get(a[ai-1], Gap)
+				trace[i][Deletion] = blk.step
+			}
+			if bi > 0 && k > 0 {
+				blk := enterCell(cur[k-1], Insertion, open,
+					!local && ai == 0 && bi == 1)
+				cur[k][Insertion] = blk.score + s.get(Gap, b[bi-1])
+				trace[i][Insertion] = blk.step
+			}
+
+			if local && cur[k][Match] > score {
+				end, last, score = i, Match, cur[k][Match]
+			}
+			if !local && ai == an-1 && bi == len(b) {
+				blk := decideOnStep(cur[k][Match], cur[k][Deletion],
+					cur[k][Insertion])
+				if ai == 0 && bi == 0 { // Empty alignment.
+					blk = block{}
+				}
+				end, last, score = i, blk.step, blk.score
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return trace, end, last, score
+}
+
+// Returns whether the alignment that starts at (ai,bi) touches the edge of the
+// band of diagonals d-w through d+w, where an adjacent cell of the table is
+// outside the band. an and bn are the lengths of the sequences.
+func touchesBand(steps []Step, ai, bi, an, bn, w, d int) bool {
+	check := func() bool {
+		k := bi - ai - d + w
+		return k == 0 && (bi > 0 || ai < an) || k == 2*w && (bi < bn || ai > 0)
+	}
+	if check() {
+		return true
+	}
+	for _, step := range steps {
+		if step != Insertion {
+			ai++
+		}
+		if step != Deletion {
+			bi++
+		}
+		if check() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package align
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestGlobalBanded(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 1,
+		{'b', 'b'}: 1,
+		{'a', 'b'}: -1,
+	}.Symmetrical().WithGaps(-1, -1)
+	tests := []struct {
+		a, b      string
+		w, d      int
+		want      []Step
+		wantScore float64
+		wantEdge  bool
+	}{
+		{"abab", "abab", 1, 0, []Step{Match, Match, Match, Match}, 4, false},
+		{"abab", "abab", 0, 0, []Step{Match, Match, Match, Match}, 4, true},
+		{"abab", "bab", 1, 0, []Step{Deletion, Match, Match, Match}, 1, true},
+		{"abab", "bab", 2, -1, []Step{Deletion, Match, Match, Match}, 1, false},
+		{"aabb", "bbaa", 1, 0, []Step{Match, Match, Match, Match}, -4, false},
+	}
+	for _, test := range tests {
+		got, gotScore, gotEdge := GlobalBanded(
+			[]byte(test.a), []byte(test.b), m, test.w, test.d)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("GlobalBanded(%q,%q,%v,%v)=%v, want %v",
+				test.a, test.b, test.w, test.d, got, test.want)
+		}
+		if gotScore != test.wantScore {
+			t.Errorf("GlobalBanded(%q,%q,%v,%v) score=%v, want %v",
+				test.a, test.b, test.w, test.d, gotScore, test.wantScore)
+		}
+		if gotEdge != test.wantEdge {
+			t.Errorf("GlobalBanded(%q,%q,%v,%v) edge=%v, want %v",
+				test.a, test.b, test.w, test.d, gotEdge, test.wantEdge)
+		}
+	}
+}
+
+func TestGlobalBanded_bad(t *testing.T) {
+	tests := []struct {
+		a, b string
+		w, d int
+	}{
+		{"aaaa", "aa", 1, 0},
+		{"aa", "aaaa", 1, 0},
+		{"aa", "aa", 1, 2},
+		{"aa", "aa", -1, 0},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("GlobalBanded(%q,%q,%v,%v) succeeded, want panic",
+						test.a, test.b, test.w, test.d)
+				}
+			}()
+			GlobalBanded([]byte(test.a), []byte(test.b), Levenshtein,
+				test.w, test.d)
+		}()
+	}
+}
+
+func TestBanded_random(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 3,
+		{'b', 'b'}: 2,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -2,
+		{'b', 'c'}: 0,
+		{'a', Gap}: -1,
+		{'b', Gap}: -2,
+		{'c', Gap}: -1,
+		{Gap, Gap}: -2,
+	}.Symmetrical()
+	for range 1000 {
+		a, b := randomSeq(rand.IntN(15), "abc"), randomSeq(rand.IntN(15), "abc")
+		k := len(b) - len(a)
+		w := rand.IntN(10) + max(k, -k)
+		d := rand.IntN(2*w-max(k, -k)+1) - w + max(k, 0)
+
+		steps, got, edge := GlobalBanded(a, b, m, w, d)
+		_, want := Global(a, b, m)
+		full := d-w <= -len(a) && d+w >= len(b) // Band covers the table.
+		if got > want || full && (got != want || edge) {
+			t.Fatalf("GlobalBanded(%q,%q,%v,%v)=%v,%v, want %v",
+				a, b, w, d, got, edge, want)
+		}
+		if s := scoreSteps(a, b, steps, m); s != got {
+			t.Fatalf("GlobalBanded(%q,%q,%v,%v)=%v with score %v, "+
+				"but steps score %v", a, b, w, d, steps, got, s)
+		}
+		if !inBand(steps, 0, 0, w, d) {
+			t.Fatalf("GlobalBanded(%q,%q,%v,%v)=%v, exceeds band",
+				a, b, w, d, steps)
+		}
+
+		d = rand.IntN(21) - 10
+		steps, ai, bi, got, edge := LocalBanded(a, b, m, w, d)
+		_, _, _, want = Local(a, b, m)
+		full = d-w <= -len(a) && d+w >= len(b)
+		if got > want || full && (got != want || edge) {
+			t.Fatalf("LocalBanded(%q,%q,%v,%v)=%v,%v, want %v",
+				a, b, w, d, got, edge, want)
+		}
+		if got == 0 {
+			continue
+		}
+		if s := scoreSteps(a[ai:], b[bi:], steps, m); s != got {
+			t.Fatalf("LocalBanded(%q,%q,%v,%v)=%v,%v,%v with score %v, "+
+				"but steps score %v", a, b, w, d, steps, ai, bi, got, s)
+		}
+		if !inBand(steps, ai, bi, w, d) {
+			t.Fatalf("LocalBanded(%q,%q,%v,%v)=%v,%v,%v, exceeds band",
+				a, b, w, d, steps, ai, bi)
+		}
+	}
+}
+
+// Returns whether the alignment that starts at (ai,bi) stays in the band.
+func inBand(steps []Step, ai, bi, w, d int) bool {
+	if bi-ai < d-w || bi-ai > d+w {
+		return false
+	}
+	for _, step := range steps {
+		if step != Insertion {
+			ai++
+		}
+		if step != Deletion {
+			bi++
+		}
+		if bi-ai < d-w || bi-ai > d+w {
+			return false
+		}
+	}
+	return true
+}
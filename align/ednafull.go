@@ -0,0 +1,295 @@
+package align
+
+func init() {
+	EDNAFULL = SubstitutionMatrix{
+		{'A', 'A'}: 5,
+		{'A', 'B'}: -4,
+		{'A', 'C'}: -4,
+		{'A', 'D'}: -1,
+		{'A', 'G'}: -4,
+		{'A', 'H'}: -1,
+		{'A', 'K'}: -4,
+		{'A', 'M'}: 1,
+		{'A', 'N'}: -2,
+		{'A', 'R'}: 1,
+		{'A', 'S'}: -4,
+		{'A', 'T'}: -4,
+		{'A', 'U'}: -4,
+		{'A', 'V'}: -1,
+		{'A', 'W'}: 1,
+		{'A', 'Y'}: -4,
+		{'A', Gap}: -0.5,
+		{'B', 'A'}: -4,
+		{'B', 'B'}: -1,
+		{'B', 'C'}: -1,
+		{'B', 'D'}: -2,
+		{'B', 'G'}: -1,
+		{'B', 'H'}: -2,
+		{'B', 'K'}: -1,
+		{'B', 'M'}: -3,
+		{'B', 'N'}: -1,
+		{'B', 'R'}: -3,
+		{'B', 'S'}: -1,
+		{'B', 'T'}: -1,
+		{'B', 'U'}: -1,
+		{'B', 'V'}: -2,
+		{'B', 'W'}: -3,
+		{'B', 'Y'}: -1,
+		{'B', Gap}: -0.5,
+		{'C', 'A'}: -4,
+		{'C', 'B'}: -1,
+		{'C', 'C'}: 5,
+		{'C', 'D'}: -4,
+		{'C', 'G'}: -4,
+		{'C', 'H'}: -1,
+		{'C', 'K'}: -4,
+		{'C', 'M'}: 1,
+		{'C', 'N'}: -2,
+		{'C', 'R'}: -4,
+		{'C', 'S'}: 1,
+		{'C', 'T'}: -4,
+		{'C', 'U'}: -4,
+		{'C', 'V'}: -1,
+		{'C', 'W'}: -4,
+		{'C', 'Y'}: 1,
+		{'C', Gap}: -0.5,
+		{'D', 'A'}: -1,
+		{'D', 'B'}: -2,
+		{'D', 'C'}: -4,
+		{'D', 'D'}: -1,
+		{'D', 'G'}: -1,
+		{'D', 'H'}: -2,
+		{'D', 'K'}: -1,
+		{'D', 'M'}: -3,
+		{'D', 'N'}: -1,
+		{'D', 'R'}: -1,
+		{'D', 'S'}: -3,
+		{'D', 'T'}: -1,
+		{'D', 'U'}: -1,
+		{'D', 'V'}: -2,
+		{'D', 'W'}: -1,
+		{'D', 'Y'}: -3,
+		{'D', Gap}: -0.5,
+		{'G', 'A'}: -4,
+		{'G', 'B'}: -1,
+		{'G', 'C'}: -4,
+		{'G', 'D'}: -1,
+		{'G', 'G'}: 5,
+		{'G', 'H'}: -4,
+		{'G', 'K'}: 1,
+		{'G', 'M'}: -4,
+		{'G', 'N'}: -2,
+		{'G', 'R'}: 1,
+		{'G', 'S'}: 1,
+		{'G', 'T'}: -4,
+		{'G', 'U'}: -4,
+		{'G', 'V'}: -1,
+		{'G', 'W'}: -4,
+		{'G', 'Y'}: -4,
+		{'G', Gap}: -0.5,
+		{'H', 'A'}: -1,
+		{'H', 'B'}: -2,
+		{'H', 'C'}: -1,
+		{'H', 'D'}: -2,
+		{'H', 'G'}: -4,
+		{'H', 'H'}: -1,
+		{'H', 'K'}: -3,
+		{'H', 'M'}: -1,
+		{'H', 'N'}: -1,
+		{'H', 'R'}: -3,
+		{'H', 'S'}: -3,
+		{'H', 'T'}: -1,
+		{'H', 'U'}: -1,
+		{'H', 'V'}: -2,
+		{'H', 'W'}: -1,
+		{'H', 'Y'}: -1,
+		{'H', Gap}: -0.5,
+		{'K', 'A'}: -4,
+		{'K', 'B'}: -1,
+		{'K', 'C'}: -4,
+		{'K', 'D'}: -1,
+		{'K', 'G'}: 1,
+		{'K', 'H'}: -3,
+		{'K', 'K'}: -1,
+		{'K', 'M'}: -4,
+		{'K', 'N'}: -1,
+		{'K', 'R'}: -2,
+		{'K', 'S'}: -2,
+		{'K', 'T'}: 1,
+		{'K', 'U'}: 1,
+		{'K', 'V'}: -3,
+		{'K', 'W'}: -2,
+		{'K', 'Y'}: -2,
+		{'K', Gap}: -0.5,
+		{'M', 'A'}: 1,
+		{'M', 'B'}: -3,
+		{'M', 'C'}: 1,
+		{'M', 'D'}: -3,
+		{'M', 'G'}: -4,
+		{'M', 'H'}: -1,
+		{'M', 'K'}: -4,
+		{'M', 'M'}: -1,
+		{'M', 'N'}: -1,
+		{'M', 'R'}: -2,
+		{'M', 'S'}: -2,
+		{'M', 'T'}: -4,
+		{'M', 'U'}: -4,
+		{'M', 'V'}: -1,
+		{'M', 'W'}: -2,
+		{'M', 'Y'}: -2,
+		{'M', Gap}: -0.5,
+		{'N', 'A'}: -2,
+		{'N', 'B'}: -1,
+		{'N', 'C'}: -2,
+		{'N', 'D'}: -1,
+		{'N', 'G'}: -2,
+		{'N', 'H'}: -1,
+		{'N', 'K'}: -1,
+		{'N', 'M'}: -1,
+		{'N', 'N'}: -1,
+		{'N', 'R'}: -1,
+		{'N', 'S'}: -1,
+		{'N', 'T'}: -2,
+		{'N', 'U'}: -2,
+		{'N', 'V'}: -1,
+		{'N', 'W'}: -1,
+		{'N', 'Y'}: -1,
+		{'N', Gap}: -0.5,
+		{'R', 'A'}: 1,
+		{'R', 'B'}: -3,
+		{'R', 'C'}: -4,
+		{'R', 'D'}: -1,
+		{'R', 'G'}: 1,
+		{'R', 'H'}: -3,
+		{'R', 'K'}: -2,
+		{'R', 'M'}: -2,
+		{'R', 'N'}: -1,
+		{'R', 'R'}: -1,
+		{'R', 'S'}: -2,
+		{'R', 'T'}: -4,
+		{'R', 'U'}: -4,
+		{'R', 'V'}: -1,
+		{'R', 'W'}: -2,
+		{'R', 'Y'}: -4,
+		{'R', Gap}: -0.5,
+		{'S', 'A'}: -4,
+		{'S', 'B'}: -1,
+		{'S', 'C'}: 1,
+		{'S', 'D'}: -3,
+		{'S', 'G'}: 1,
+		{'S', 'H'}: -3,
+		{'S', 'K'}: -2,
+		{'S', 'M'}: -2,
+		{'S', 'N'}: -1,
+		{'S', 'R'}: -2,
+		{'S', 'S'}: -1,
+		{'S', 'T'}: -4,
+		{'S', 'U'}: -4,
+		{'S', 'V'}: -1,
+		{'S', 'W'}: -4,
+		{'S', 'Y'}: -2,
+		{'S', Gap}: -0.5,
+		{'T', 'A'}: -4,
+		{'T', 'B'}: -1,
+		{'T', 'C'}: -4,
+		{'T', 'D'}: -1,
+		{'T', 'G'}: -4,
+		{'T', 'H'}: -1,
+		{'T', 'K'}: 1,
+		{'T', 'M'}: -4,
+		{'T', 'N'}: -2,
+		{'T', 'R'}: -4,
+		{'T', 'S'}: -4,
+		{'T', 'T'}: 5,
+		{'T', 'U'}: 5,
+		{'T', 'V'}: -4,
+		{'T', 'W'}: 1,
+		{'T', 'Y'}: 1,
+		{'T', Gap}: -0.5,
+		{'U', 'A'}: -4,
+		{'U', 'B'}: -1,
+		{'U', 'C'}: -4,
+		{'U', 'D'}: -1,
+		{'U', 'G'}: -4,
+		{'U', 'H'}: -1,
+		{'U', 'K'}: 1,
+		{'U', 'M'}: -4,
+		{'U', 'N'}: -2,
+		{'U', 'R'}: -4,
+		{'U', 'S'}: -4,
+		{'U', 'T'}: 5,
+		{'U', 'U'}: 5,
+		{'U', 'V'}: -4,
+		{'U', 'W'}: 1,
+		{'U', 'Y'}: 1,
+		{'U', Gap}: -0.5,
+		{'V', 'A'}: -1,
+		{'V', 'B'}: -2,
+		{'V', 'C'}: -1,
+		{'V', 'D'}: -2,
+		{'V', 'G'}: -1,
+		{'V', 'H'}: -2,
+		{'V', 'K'}: -3,
+		{'V', 'M'}: -1,
+		{'V', 'N'}: -1,
+		{'V', 'R'}: -1,
+		{'V', 'S'}: -1,
+		{'V', 'T'}: -4,
+		{'V', 'U'}: -4,
+		{'V', 'V'}: -1,
+		{'V', 'W'}: -3,
+		{'V', 'Y'}: -3,
+		{'V', Gap}: -0.5,
+		{'W', 'A'}: 1,
+		{'W', 'B'}: -3,
+		{'W', 'C'}: -4,
+		{'W', 'D'}: -1,
+		{'W', 'G'}: -4,
+		{'W', 'H'}: -1,
+		{'W', 'K'}: -2,
+		{'W', 'M'}: -2,
+		{'W', 'N'}: -1,
+		{'W', 'R'}: -2,
+		{'W', 'S'}: -4,
+		{'W', 'T'}: 1,
+		{'W', 'U'}: 1,
+		{'W', 'V'}: -3,
+		{'W', 'W'}: -1,
+		{'W', 'Y'}: -2,
+		{'W', Gap}: -0.5,
+		{'Y', 'A'}: -4,
+		{'Y', 'B'}: -1,
+		{'Y', 'C'}: 1,
+		{'Y', 'D'}: -3,
+		{'Y', 'G'}: -4,
+		{'Y', 'H'}: -1,
+		{'Y', 'K'}: -2,
+		{'Y', 'M'}: -2,
+		{'Y', 'N'}: -1,
+		{'Y', 'R'}: -4,
+		{'Y', 'S'}: -2,
+		{'Y', 'T'}: 1,
+		{'Y', 'U'}: 1,
+		{'Y', 'V'}: -3,
+		{'Y', 'W'}: -2,
+		{'Y', 'Y'}: -1,
+		{'Y', Gap}: -0.5,
+		{Gap, 'A'}: -0.5,
+		{Gap, 'B'}: -0.5,
+		{Gap, 'C'}: -0.5,
+		{Gap, 'D'}: -0.5,
+		{Gap, 'G'}: -0.5,
+		{Gap, 'H'}: -0.5,
+		{Gap, 'K'}: -0.5,
+		{Gap, 'M'}: -0.5,
+		{Gap, 'N'}: -0.5,
+		{Gap, 'R'}: -0.5,
+		{Gap, 'S'}: -0.5,
+		{Gap, 'T'}: -0.5,
+		{Gap, 'U'}: -0.5,
+		{Gap, 'V'}: -0.5,
+		{Gap, 'W'}: -0.5,
+		{Gap, 'Y'}: -0.5,
+		{Gap, Gap}: -9.5,
+	}
+}
@@ -0,0 +1,122 @@
+// Alignment rendering and statistics.
+
+package align
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stats holds summary statistics of an alignment.
+type Stats struct {
+	Length     int // Number of alignment columns (steps)
+	Identities int // Matches of identical characters
+	Positives  int // Matches with a positive score, including identities
+	Mismatches int // Matches of non-identical characters
+	Gaps       int // Characters aligned with gaps
+	GapOpens   int // Runs of consecutive deletions or insertions
+}
+
+// Identity returns the fraction of alignment columns that are identities.
+func (s Stats) Identity() float64 {
+	if s.Length == 0 {
+		return 0
+	}
+	return float64(s.Identities) / float64(s.Length)
+}
+
+// Similarity returns the fraction of alignment columns that are positives.
+func (s Stats) Similarity() float64 {
+	if s.Length == 0 {
+		return 0
+	}
+	return float64(s.Positives) / float64(s.Length)
+}
+
+// Summarize returns the statistics of the alignment of a and b, given by steps.
+// The steps should start at the beginnings of a and b, so for local alignments
+// use a[ai:] and b[bi:]. m determines which matches are positives. Pairs that
+// are missing from m are not positives. m may be nil.
+func Summarize(a, b []byte, steps []Step, m SubstitutionMatrix) Stats {
+	s := Stats{Length: len(steps)}
+	for i, step := range steps {
+		if step != Match && (i == 0 || steps[i-1] != step) {
+			s.GapOpens++
+		}
+		switch step {
+		case Match:
+			if a[0] == b[0] {
+				s.Identities++
+				s.Positives++
+			} else {
+				s.Mismatches++
+				if m[[2]byte{a[0], b[0]}] > 0 {
+					s.Positives++
+				}
+			}
+			a, b = a[1:], b[1:]
+		case Deletion:
+			s.Gaps++
+			a = a[1:]
+		case Insertion:
+			s.Gaps++
+			b = b[1:]
+		default:
+			panic(fmt.Sprintf("unknown step value: %d", step))
+		}
+	}
+	return s
+}
+
+// Format returns a textual representation of the alignment of a and b, given by
+// steps, as in the package documentation. The steps should start at the
+// beginnings of a and b, so for local alignments use a[ai:] and b[bi:]. The
+// middle line marks identities with '|' and other positives with ':', according
+// to m. m may be nil. Lines are wrapped to the given width, with an empty line
+// between blocks. A width of 0 or less means no wrapping.
+func Format(a, b []byte, steps []Step, m SubstitutionMatrix, width int) string {
+	top := make([]byte, 0, len(steps))
+	mid := make([]byte, 0, len(steps))
+	bottom := make([]byte, 0, len(steps))
+	for _, step := range steps {
+		switch step {
+		case Match:
+			top = append(top, a[0])
+			bottom = append(bottom, b[0])
+			if a[0] == b[0] {
+				mid = append(mid, '|')
+			} else if m[[2]byte{a[0], b[0]}] > 0 {
+				mid = append(mid, ':')
+			} else {
+				mid = append(mid, ' ')
+			}
+			a, b = a[1:], b[1:]
+		case Deletion:
+			top = append(top, a[0])
+			mid = append(mid, ' ')
+			bottom = append(bottom, '-')
+			a = a[1:]
+		case Insertion:
+			top = append(top, '-')
+			mid = append(mid, ' ')
+			bottom = append(bottom, b[0])
+			b = b[1:]
+		default:
+			panic(fmt.Sprintf("unknown step value: %d", step))
+		}
+	}
+
+	if width <= 0 {
+		width = max(len(steps), 1)
+	}
+	buf := &strings.Builder{}
+	for i := 0; i < len(steps); i += width {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		to := min(i+width, len(steps))
+		fmt.Fprintf(buf, "%s\n%s\n%s\n", top[i:to],
+			strings.TrimRight(string(mid[i:to]), " "), bottom[i:to])
+	}
+	return buf.String()
+}
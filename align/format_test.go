@@ -0,0 +1,52 @@
+package align
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	a, b := []byte("blablab"), []byte("blrblbr")
+	steps := []Step{Match, Match, Match, Match, Match, Deletion, Match, Insertion}
+	m := SubstitutionMatrix{{'a', 'r'}: 1}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{0, "blablab-\n||:|| |\nblrbl-br\n"},
+		{5, "blabl\n||:||\nblrbl\n\nab-\n |\n-br\n"},
+		{4, "blab\n||:|\nblrb\n\nlab-\n| |\nl-br\n"},
+	}
+	for _, test := range tests {
+		if got := Format(a, b, steps, m, test.width); got != test.want {
+			t.Errorf("Format(%q,%q,%v,%v)=%q, want %q",
+				a, b, steps, test.width, got, test.want)
+		}
+	}
+	if got, want := Format(a, b, steps, nil, 0),
+		"blablab-\n|| || |\nblrbl-br\n"; got != want {
+		t.Errorf("Format(%q,%q,%v,nil,0)=%q, want %q", a, b, steps, got, want)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	a, b := []byte("blablabxx"), []byte("blrblbr")
+	steps := []Step{Match, Match, Match, Match, Match, Deletion, Match,
+		Insertion, Deletion, Deletion}
+	m := SubstitutionMatrix{{'a', 'r'}: 1, {'x', 'r'}: 2}
+	want := Stats{
+		Length:     10,
+		Identities: 5,
+		Positives:  6,
+		Mismatches: 1,
+		Gaps:       4,
+		GapOpens:   3,
+	}
+	got := Summarize(a, b, steps, m)
+	if got != want {
+		t.Fatalf("Summarize(%q,%q,%v)=%+v, want %+v", a, b, steps, got, want)
+	}
+	if got, want := got.Identity(), 0.5; got != want {
+		t.Errorf("Identity()=%v, want %v", got, want)
+	}
+	if got, want := got.Similarity(), 0.6; got != want {
+		t.Errorf("Similarity()=%v, want %v", got, want)
+	}
+}
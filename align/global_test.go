@@ -1,6 +1,7 @@
 package align
 
 import (
+	"math/rand/v2"
 	"reflect"
 	"testing"
 )
@@ -53,3 +54,116 @@ func TestLevenshtein(t *testing.T) {
 		}
 	}
 }
+
+func TestGlobal_affine(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 2,
+		{'b', 'b'}: 2,
+		{'a', 'b'}: -1,
+	}.Symmetrical().WithGaps(-3, -1)
+	tests := []struct {
+		a, b      string
+		want      []Step
+		wantScore float64
+	}{
+		{"aabb", "bb", []Step{Deletion, Deletion, Match, Match}, -1},
+		{"abba", "aa", []Step{Match, Deletion, Deletion, Match}, -1},
+		{"abab", "aab", []Step{Match, Deletion, Match, Match}, 2},
+	}
+	for _, test := range tests {
+		got, gotScore := Global([]byte(test.a), []byte(test.b), m)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Global(%q,%q)=%v, want %v", test.a, test.b, got, test.want)
+		}
+		if gotScore != test.wantScore {
+			t.Errorf("Global(%q,%q) score=%v, want %v",
+				test.a, test.b, gotScore, test.wantScore)
+		}
+	}
+}
+
+func TestGlobal_exhaustive(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 3,
+		{'b', 'b'}: 2,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -2,
+		{'b', 'c'}: 0,
+		{'a', Gap}: -1,
+		{'b', Gap}: -2,
+		{'c', Gap}: -1,
+		{Gap, Gap}: -3,
+	}.Symmetrical()
+	for range 200 {
+		a, b := randomSeq(rand.IntN(6), "abc"), randomSeq(rand.IntN(6), "abc")
+		want := negInf
+		for _, steps := range allAlignments(len(a), len(b)) {
+			want = max(want, scoreSteps(a, b, steps, m))
+		}
+		steps, got := Global(a, b, m)
+		if got != want {
+			t.Fatalf("Global(%q,%q) score=%v, want %v", a, b, got, want)
+		}
+		if s := scoreSteps(a, b, steps, m); s != got {
+			t.Fatalf("Global(%q,%q)=%v with score %v, but steps score %v",
+				a, b, steps, got, s)
+		}
+	}
+}
+
+// Returns a random sequence of the given length, over the given alphabet.
+func randomSeq(n int, chars string) []byte {
+	seq := make([]byte, n)
+	for i := range seq {
+		seq[i] = chars[rand.IntN(len(chars))]
+	}
+	return seq
+}
+
+// Returns all the possible global alignments of sequences of the given lengths.
+func allAlignments(an, bn int) [][]Step {
+	if an == 0 && bn == 0 {
+		return [][]Step{nil}
+	}
+	var result [][]Step
+	add := func(prefixes [][]Step, step Step) {
+		for _, p := range prefixes {
+			result = append(result, append(append([]Step{}, p...), step))
+		}
+	}
+	if an > 0 && bn > 0 {
+		add(allAlignments(an-1, bn-1), Match)
+	}
+	if an > 0 {
+		add(allAlignments(an-1, bn), Deletion)
+	}
+	if bn > 0 {
+		add(allAlignments(an, bn-1), Insertion)
+	}
+	return result
+}
+
+// Returns the affine score of the given alignment steps.
+func scoreSteps(a, b []byte, steps []Step, m SubstitutionMatrix) float64 {
+	score := 0.0
+	ai, bi := 0, 0
+	for i, step := range steps {
+		if step != Match && (i == 0 || steps[i-1] != step) {
+			score += m.Get(Gap, Gap)
+		}
+		switch step {
+		case Match:
+			score += m.Get(a[ai], b[bi])
+			ai++
+			bi++
+		case Deletion:
+			score += m.Get(a[ai], Gap)
+			ai++
+		case Insertion:
+			score += m.Get(Gap, b[bi])
+			bi++
+		}
+	}
+	return score
+}
@@ -0,0 +1,209 @@
+// Affine-gap dynamic programming (Gotoh).
+
+package align
+
+import (
+	"fmt"
+	"math"
+)
+
+// Score of an impossible alignment.
+var negInf = math.Inf(-1)
+
+// Scores of the best alignments that end in a single cell, indexed by their last
+// step. Index 0 is unused.
+type cellScores [4]float64
+
+// Traceback pointers of a single cell, indexed by the last step. Each pointer is
+// the step that precedes the last step in the best alignment that ends with it,
+// or 0 if the alignment starts there. Index 0 is unused.
+type cellSteps [4]Step
+
+// Scores of aligning a single position of a sequence or a profile (a) with
+// another sequence (b). Missing scores are NaN.
+//
+// When b is a profile too, matchB and insertionB hold the scores of the
+// positions of b, and are used instead of match and insertion.
+type position struct {
+	match         *[256]float64 // Scores of aligning the position with characters
+	insertion     *[256]float64 // Scores of characters with gaps, before the position
+	deletion      float64       // Score of aligning the position with a gap
+	openDeletion  float64       // Score of opening a gap at the position
+	openInsertion float64       // Score of opening a gap before the position
+	char          int           // Character at the position, or -1 for profiles
+	matchB        []float64     // Scores of aligning the position with b's
+	insertionB    []float64     // Scores of b's positions with gaps
+}
+
+// Returns the positions of sequence a, scored with s. The last position is past
+// the end of a, and has only insertion scores.
+func seqPositions(a []byte, s *substitutionArray) []position {
+	open := s.get(Gap, Gap)
+	pos := make([]position, len(a)+1)
+	for i := range pos {
+		pos[i] = position{insertion: &s[Gap], openDeletion: open,
+			openInsertion: open, char: -1}
+		if i < len(a) {
+			pos[i].match = &s[a[i]]
+			pos[i].deletion = s.get(a[i], Gap)
+			pos[i].char = int(a[i])
+		}
+	}
+	return pos
+}
+
+// Panics with a message about a missing score of aligning position i with c,
+// where c may be Gap.
+func (p *position) panicMissing(i int, c byte) {
+	if p.char == -1 {
+		panic(fmt.Sprintf("character (%d %s) is missing from position %d of "+
+			"the profile", c, charOrGap(c), i))
+	}
+	a := byte(p.char)
+	panic(fmt.Sprintf("pair (%d %s, %d %s) is not in the substitution-matrix",
+		a, charOrGap(a), c, charOrGap(c)))
+}
+
+// Fills the Gotoh dynamic-programming table for a and b, using three states:
+// the alignment ends with a match, a deletion or an insertion. Keeps only two
+// rows of scores, and a full table of traceback pointers.
+//
+// free determines which ends of a and b may be skipped at no cost. If local is
+// true, alignments may start and end with a match anywhere. If mask is not nil,
+// cells where mask is true may not be entered with a match.
+//
+// If the positions have scores against the positions of b (insertionB), b is
+// ignored and its length is that of insertionB.
+//
+// Returns the pointers, the index of the cell where the best alignment ends,
+// its last step and its score.
+func gotoh(pos []position, b []byte, free Ends, local bool, mask []bool) (
+	trace []cellSteps, end int, last Step, score float64) {
+	an, bn := len(pos), len(b)+1
+	if pos[0].insertionB != nil {
+		bn = len(pos[0].insertionB) + 1
+	}
+	trace = make([]cellSteps, an*bn)
+	prev, cur := make([]cellScores, bn), make([]cellScores, bn)
+	score = negInf
+
+	// Whether alignments may start or end in a cell.
+	isStart := func(ai, bi int) bool {
+		return local ||
+			(ai == 0 || free&FreeStartA != 0) &&
+				(bi == 0 || free&FreeStartB != 0)
+	}
+	isEnd := func(ai, bi int) bool {
+		return local ||
+			(ai == an-1 || free&FreeEndA != 0) &&
+				(bi == bn-1 || free&FreeEndB != 0)
+	}
+
+	for ai := range an {
+		p, q := &pos[max(ai-1, 0)], &pos[ai] // Deletions use p, insertions use q
+		for bi := range bn {
+			i := ai*bn + bi
+			cur[bi] = cellScores{negInf, negInf, negInf, negInf}
+
+			if ai > 0 && bi > 0 && (mask == nil || !mask[i]) {
+				blk := enterCell(prev[bi-1], Match, 0, isStart(ai-1, bi-1))
+				var x float64
+				if p.matchB != nil {
+					x = p.matchB[bi-1]
+				} else if x = p.match[b[bi-1]]; math.IsNaN(x) {
+					p.panicMissing(ai-1, b[bi-1])
+				}
+				cur[bi][Match] = blk.score + x
+				trace[i][Match] = blk.step
+			}
+			if ai > 0 {
+				blk := enterCell(prev[bi], Deletion, p.openDeletion,
+					!local && isStart(ai-1, bi))
+				cur[bi][Deletion] = blk.score + p.deletion
+				trace[i][Deletion] = blk.step
+			}
+			if bi > 0 {
+				var x float64
+				if q.insertionB != nil {
+					x = q.insertionB[bi-1]
+				} else if x = q.insertion[b[bi-1]]; math.IsNaN(x) {
+					q.panicMissing(ai, b[bi-1])
+				}
+				blk := enterCell(cur[bi-1], Insertion, q.openInsertion,
+					!local && isStart(ai, bi-1))
+				cur[bi][Insertion] = blk.score + x
+				trace[i][Insertion] = blk.step
+			}
+
+			if !isEnd(ai, bi) {
+				continue
+			}
+			var blk block
+			if local { // Local alignments end with a match.
+				blk = block{score: cur[bi][Match], step: Match}
+			} else {
+				blk = decideOnStep(cur[bi][Match], cur[bi][Deletion],
+					cur[bi][Insertion])
+				if isStart(ai, bi) && blk.score <= 0 { // Empty alignment.
+					blk = block{}
+				}
+			}
+			if blk.score > score {
+				end, last, score = i, blk.step, blk.score
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return trace, end, last, score
+}
+
+// Returns the best way to enter a cell with the given step, from a preceding
+// cell with scores p. open is the score of opening a gap with the step. If
+// start is true, the alignment may also start at the preceding cell, in which
+// case the returned step is 0.
+func enterCell(p cellScores, step Step, open float64, start bool) block {
+	var blk block
+	var startScore float64
+	switch step {
+	case Match:
+		blk = decideOnStep(p[Match], p[Deletion], p[Insertion])
+	case Deletion:
+		blk = decideOnStep(p[Match]+open, p[Deletion], p[Insertion]+open)
+		startScore = open
+	case Insertion:
+		blk = decideOnStep(p[Match]+open, p[Deletion]+open, p[Insertion])
+		startScore = open
+	}
+	if start && startScore >= blk.score {
+		return block{score: startScore}
+	}
+	return blk
+}
+
+// Reproduces the alignment steps that lead to cell i, where the last step is
+// the given one. Returns the steps and the index of the cell where the alignment
+// starts.
+func traceSteps(trace []cellSteps, bn, i int, last Step) ([]Step, int) {
+	var steps []Step
+	for step := last; i > 0 && step != 0; {
+		steps = append(steps, step)
+		prev := trace[i][step]
+		switch step {
+		case Match:
+			i -= bn + 1
+		case Deletion:
+			i -= bn
+		case Insertion:
+			i -= 1
+		}
+		step = prev
+	}
+	if i < 0 {
+		panic(fmt.Sprintf("bad i: %v, expected at least 0", i))
+	}
+	// Reverse steps.
+	for i := 0; i < len(steps)/2; i++ {
+		steps[i], steps[len(steps)-1-i] = steps[len(steps)-1-i], steps[i]
+	}
+	return steps, i
+}
@@ -0,0 +1,278 @@
+// Karlin-Altschul statistics.
+
+package align
+
+import (
+	"fmt"
+	"math"
+)
+
+// KarlinAltschul holds the statistical parameters of local alignment scores,
+// which allow comparing scores across substitution matrices.
+//
+// Karlin S, Altschul SF. Methods for assessing the statistical significance of
+// molecular sequence features by using general scoring schemes. PNAS. 1990.
+type KarlinAltschul struct {
+	Lambda float64 // Scale of the score distribution
+	K      float64 // Search space correction
+	H      float64 // Relative entropy of the scores, in nats per aligned pair
+}
+
+// BitScore returns the normalized score in bits, of an alignment with the given
+// raw score.
+func (ka KarlinAltschul) BitScore(score float64) float64 {
+	return (ka.Lambda*score - math.Log(ka.K)) / math.Ln2
+}
+
+// EValue returns the expected number of alignments with at least the given raw
+// score, when aligning a query of length m against a database of total length n.
+func (ka KarlinAltschul) EValue(score float64, m, n int) float64 {
+	return ka.K * float64(m) * float64(n) * math.Exp(-ka.Lambda*score)
+}
+
+// NewKarlinAltschul calculates the parameters for ungapped local alignment with
+// the given matrix, where characters appear with the given background
+// frequencies. Only pairs of characters in freqs are considered. Scores should
+// be integers, their expected value should be negative, and at least one score
+// should be positive.
+//
+// Gapped alignment parameters cannot be calculated analytically. For those,
+// see GappedKarlinAltschul.
+func NewKarlinAltschul(m SubstitutionMatrix, freqs map[byte]float64) (
+	KarlinAltschul, error) {
+	// Score distribution.
+	sum := 0.0
+	for _, f := range freqs {
+		sum += f
+	}
+	probs := map[int]float64{}
+	for a, fa := range freqs {
+		for b, fb := range freqs {
+			s, ok := m[[2]byte{a, b}]
+			if !ok {
+				return KarlinAltschul{}, fmt.Errorf(
+					"pair (%s,%s) is not in the substitution-matrix",
+					charOrGap(a), charOrGap(b))
+			}
+			if s != math.Trunc(s) {
+				return KarlinAltschul{}, fmt.Errorf(
+					"score of (%s,%s) is not an integer: %v",
+					charOrGap(a), charOrGap(b), s)
+			}
+			probs[int(s)] += fa * fb / sum / sum
+		}
+	}
+	low, high, mean, span := 0, 0, 0.0, 0
+	for s, p := range probs {
+		if p == 0 {
+			continue
+		}
+		low, high = min(low, s), max(high, s)
+		mean += float64(s) * p
+		span = gcd(span, s)
+	}
+	if mean >= 0 {
+		return KarlinAltschul{}, fmt.Errorf(
+			"expected score should be negative, got %v", mean)
+	}
+	if high <= 0 {
+		return KarlinAltschul{}, fmt.Errorf("no positive scores")
+	}
+
+	// Lambda is the positive root of sum(p*e^(lambda*s))=1.
+	moment := func(lambda float64) float64 {
+		result := 0.0
+		for s, p := range probs {
+			result += p * math.Exp(lambda*float64(s))
+		}
+		return result
+	}
+	lo, hi := 0.0, 1.0
+	for moment(hi) < 1 {
+		lo, hi = hi, hi*2
+	}
+	for range 100 {
+		mid := (lo + hi) / 2
+		if moment(mid) < 1 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	lambda := (lo + hi) / 2
+
+	h := 0.0
+	for s, p := range probs {
+		h += float64(s) * p * math.Exp(lambda*float64(s))
+	}
+	h *= lambda
+
+	// K = lambda*span*exp(-2*sigma) / (H*(1-exp(-lambda*span))), where sigma is
+	// the sum over k of (E[exp(lambda*S_k); S_k<0] + P(S_k>=0)) / k, and S_k is
+	// the sum of k scores.
+	low, high = low/span, high/span
+	step := make([]float64, high-low+1)
+	for s, p := range probs {
+		step[s/span-low] += p
+	}
+	dist := []float64{1} // Distribution of S_k, starting at k*low.
+	sigma := 0.0
+	for k := 1; k <= 500; k++ {
+		next := make([]float64, len(dist)+len(step)-1)
+		for i, p := range dist {
+			for j, q := range step {
+				next[i+j] += p * q
+			}
+		}
+		dist = next
+
+		term := 0.0
+		for i, p := range dist {
+			s := k*low + i
+			if s < 0 {
+				term += p * math.Exp(lambda*float64(s*span))
+			} else {
+				term += p
+			}
+		}
+		sigma += term / float64(k)
+		if term/float64(k) < 1e-10*sigma {
+			break
+		}
+	}
+	ls := lambda * float64(span)
+	kk := ls * math.Exp(-2*sigma) / (h * -math.Expm1(-ls))
+
+	return KarlinAltschul{Lambda: lambda, K: kk, H: h}, nil
+}
+
+// Returns the greatest common divisor of a and b.
+func gcd(a, b int) int {
+	a, b = max(a, -a), max(b, -b)
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// A MatrixName identifies a substitution matrix of this package, for looking up
+// precalculated parameters.
+type MatrixName string
+
+// Names of the matrices that have precalculated gapped parameters.
+const (
+	NameBLOSUM45 MatrixName = "BLOSUM45"
+	NameBLOSUM62 MatrixName = "BLOSUM62"
+	NameBLOSUM80 MatrixName = "BLOSUM80"
+	NamePAM250   MatrixName = "PAM250"
+)
+
+// GappedKarlinAltschul returns precalculated parameters for gapped local
+// alignment, with the named matrix and gap scores, as in m.WithGaps(open,
+// extend). Returns false if the parameters are not available.
+//
+// Available are the values that BLAST uses with the Robinson background
+// frequencies, for the following matrices and gap scores (open,extend):
+//
+//   - BLOSUM45: (-13,-3) through (-10,-3), (-16,-2) through (-12,-2), and
+//     (-19,-1) through (-16,-1).
+//   - BLOSUM62: (-11,-2) through (-6,-2), and (-13,-1) through (-9,-1).
+//   - BLOSUM80: (-25,-2), (-13,-2), (-9,-2) through (-6,-2), and (-11,-1)
+//     through (-9,-1).
+//   - PAM250: (-15,-3) through (-11,-3), (-17,-2) through (-13,-2), and
+//     (-21,-1) through (-17,-1).
+//
+// Other bundled matrices, such as PAM120, have no gapped parameters.
+func GappedKarlinAltschul(matrix MatrixName, open, extend float64) (
+	KarlinAltschul, bool) {
+	ka, ok := gappedKarlinAltschul[gappedKey{matrix, open, extend}]
+	return ka, ok
+}
+
+// Key for precalculated gapped parameters.
+type gappedKey struct {
+	matrix       MatrixName
+	open, extend float64
+}
+
+// Precalculated gapped parameters, from the BLAST source code.
+var gappedKarlinAltschul = map[gappedKey]KarlinAltschul{
+	{NameBLOSUM45, -13, -3}: {0.207, 0.049, 0.14},
+	{NameBLOSUM45, -12, -3}: {0.199, 0.039, 0.11},
+	{NameBLOSUM45, -11, -3}: {0.190, 0.031, 0.095},
+	{NameBLOSUM45, -10, -3}: {0.179, 0.023, 0.075},
+	{NameBLOSUM45, -16, -2}: {0.210, 0.051, 0.14},
+	{NameBLOSUM45, -15, -2}: {0.203, 0.041, 0.12},
+	{NameBLOSUM45, -14, -2}: {0.195, 0.032, 0.10},
+	{NameBLOSUM45, -13, -2}: {0.185, 0.024, 0.084},
+	{NameBLOSUM45, -12, -2}: {0.171, 0.016, 0.061},
+	{NameBLOSUM45, -19, -1}: {0.205, 0.040, 0.11},
+	{NameBLOSUM45, -18, -1}: {0.198, 0.032, 0.10},
+	{NameBLOSUM45, -17, -1}: {0.189, 0.024, 0.079},
+	{NameBLOSUM45, -16, -1}: {0.176, 0.016, 0.063},
+
+	{NameBLOSUM62, -11, -2}: {0.297, 0.082, 0.27},
+	{NameBLOSUM62, -10, -2}: {0.291, 0.075, 0.23},
+	{NameBLOSUM62, -9, -2}:  {0.279, 0.058, 0.19},
+	{NameBLOSUM62, -8, -2}:  {0.264, 0.045, 0.15},
+	{NameBLOSUM62, -7, -2}:  {0.239, 0.027, 0.10},
+	{NameBLOSUM62, -6, -2}:  {0.201, 0.012, 0.061},
+	{NameBLOSUM62, -13, -1}: {0.292, 0.071, 0.23},
+	{NameBLOSUM62, -12, -1}: {0.283, 0.059, 0.19},
+	{NameBLOSUM62, -11, -1}: {0.267, 0.041, 0.14},
+	{NameBLOSUM62, -10, -1}: {0.243, 0.024, 0.10},
+	{NameBLOSUM62, -9, -1}:  {0.206, 0.010, 0.052},
+
+	{NameBLOSUM80, -25, -2}: {0.342, 0.17, 0.66},
+	{NameBLOSUM80, -13, -2}: {0.336, 0.15, 0.57},
+	{NameBLOSUM80, -9, -2}:  {0.319, 0.11, 0.42},
+	{NameBLOSUM80, -8, -2}:  {0.308, 0.090, 0.35},
+	{NameBLOSUM80, -7, -2}:  {0.293, 0.070, 0.27},
+	{NameBLOSUM80, -6, -2}:  {0.268, 0.045, 0.19},
+	{NameBLOSUM80, -11, -1}: {0.314, 0.095, 0.35},
+	{NameBLOSUM80, -10, -1}: {0.299, 0.071, 0.27},
+	{NameBLOSUM80, -9, -1}:  {0.279, 0.048, 0.20},
+
+	{NamePAM250, -15, -3}: {0.205, 0.049, 0.13},
+	{NamePAM250, -14, -3}: {0.200, 0.043, 0.12},
+	{NamePAM250, -13, -3}: {0.194, 0.036, 0.10},
+	{NamePAM250, -12, -3}: {0.186, 0.029, 0.085},
+	{NamePAM250, -11, -3}: {0.174, 0.020, 0.070},
+	{NamePAM250, -17, -2}: {0.204, 0.047, 0.12},
+	{NamePAM250, -16, -2}: {0.198, 0.038, 0.11},
+	{NamePAM250, -15, -2}: {0.191, 0.031, 0.087},
+	{NamePAM250, -14, -2}: {0.182, 0.024, 0.073},
+	{NamePAM250, -13, -2}: {0.171, 0.017, 0.059},
+	{NamePAM250, -21, -1}: {0.205, 0.045, 0.11},
+	{NamePAM250, -20, -1}: {0.199, 0.037, 0.10},
+	{NamePAM250, -19, -1}: {0.192, 0.029, 0.083},
+	{NamePAM250, -18, -1}: {0.183, 0.021, 0.070},
+	{NamePAM250, -17, -1}: {0.171, 0.014, 0.052},
+}
+
+// RobinsonFrequencies are amino acid background frequencies, as used in BLAST.
+//
+// Robinson AB, Robinson LR. Distribution of glutamine and asparagine residues
+// and their near neighbors in peptides and proteins. PNAS. 1991.
+var RobinsonFrequencies = map[byte]float64{
+	'A': 0.07805,
+	'C': 0.01925,
+	'D': 0.05364,
+	'E': 0.06295,
+	'F': 0.03856,
+	'G': 0.07377,
+	'H': 0.02199,
+	'I': 0.05142,
+	'K': 0.05744,
+	'L': 0.09019,
+	'M': 0.02243,
+	'N': 0.04487,
+	'P': 0.05203,
+	'Q': 0.04264,
+	'R': 0.05129,
+	'S': 0.07120,
+	'T': 0.05841,
+	'V': 0.06441,
+	'W': 0.01330,
+	'Y': 0.03216,
+}
@@ -0,0 +1,72 @@
+package align
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewKarlinAltschul(t *testing.T) {
+	// Values from the BLAST source code.
+	tests := []struct {
+		name string
+		m    SubstitutionMatrix
+		want KarlinAltschul
+	}{
+		{"BLOSUM62", BLOSUM62, KarlinAltschul{0.3176, 0.134, 0.4012}},
+		{"BLOSUM45", BLOSUM45, KarlinAltschul{0.2291, 0.0924, 0.2514}},
+		{"PAM250", PAM250, KarlinAltschul{0.2252, 0.0868, 0.2223}},
+	}
+	for _, test := range tests {
+		got, err := NewKarlinAltschul(test.m, RobinsonFrequencies)
+		if err != nil {
+			t.Fatalf("NewKarlinAltschul(%s) failed: %v", test.name, err)
+		}
+		if math.Abs(got.Lambda-test.want.Lambda) > 0.0001 ||
+			math.Abs(got.K-test.want.K) > 0.001 ||
+			math.Abs(got.H-test.want.H) > 0.0001 {
+			t.Errorf("NewKarlinAltschul(%s)=%v, want %v",
+				test.name, got, test.want)
+		}
+	}
+}
+
+func TestNewKarlinAltschul_bad(t *testing.T) {
+	freqs := map[byte]float64{'a': 0.5, 'b': 0.5}
+	tests := []SubstitutionMatrix{
+		{{'a', 'a'}: 1, {'a', 'b'}: -1, {'b', 'b'}: 1},
+		{{'a', 'a'}: 1, {'a', 'b'}: -1, {'b', 'a'}: -1, {'b', 'b'}: 1},
+		{{'a', 'a'}: -1, {'a', 'b'}: -1, {'b', 'a'}: -1, {'b', 'b'}: -1},
+		{{'a', 'a'}: 1.5, {'a', 'b'}: -2, {'b', 'a'}: -2, {'b', 'b'}: 1},
+	}
+	for _, m := range tests {
+		if got, err := NewKarlinAltschul(m, freqs); err == nil {
+			t.Errorf("NewKarlinAltschul(%v)=%v, want error", m, got)
+		}
+	}
+}
+
+func TestKarlinAltschul(t *testing.T) {
+	ka, ok := GappedKarlinAltschul(NameBLOSUM62, -11, -1)
+	if !ok {
+		t.Fatalf("GappedKarlinAltschul(BLOSUM62,-11,-1) failed")
+	}
+	if _, ok := GappedKarlinAltschul(NameBLOSUM62, -1, -11); ok {
+		t.Fatalf("GappedKarlinAltschul(BLOSUM62,-1,-11) succeeded, want fail")
+	}
+	for _, name := range []MatrixName{NameBLOSUM45, NameBLOSUM80,
+		NamePAM250} {
+		if _, ok := GappedKarlinAltschul(name, -13, -2); !ok {
+			t.Errorf("GappedKarlinAltschul(%v,-13,-2) failed", name)
+		}
+	}
+	if _, ok := GappedKarlinAltschul("PAM120", -11, -1); ok {
+		t.Errorf("GappedKarlinAltschul(PAM120,-11,-1) succeeded, want fail")
+	}
+	if got, want := ka.BitScore(100), 43.1; math.Abs(got-want) > 0.05 {
+		t.Errorf("BitScore(100)=%v, want %v", got, want)
+	}
+	if got, want := ka.EValue(100, 300, 1e6),
+		0.041*300*1e6*math.Exp(-26.7); math.Abs(got-want) > 1e-15 {
+		t.Errorf("EValue(100,300,1e6)=%v, want %v", got, want)
+	}
+}
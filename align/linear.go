@@ -0,0 +1,247 @@
+// Linear-space alignment (Hirschberg, Myers-Miller).
+
+package align
+
+import "slices"
+
+// GlobalLinear performs global alignment on a and b and finds the highest scoring
+// alignment. Returns the steps relating to a, and the alignment score.
+// Time complexity is O(len(a)*len(b)), and space complexity is
+// O(len(a)+len(b)).
+//
+// Returns the same score as Global, but may return a different alignment if
+// several alignments have the highest score.
+//
+// Uses Hirschberg's algorithm, with Myers and Miller's extension to affine gaps.
+func GlobalLinear(a, b []byte, m SubstitutionMatrix) (
+	steps []Step, score float64) {
+	s := m.toArray()
+	open := s.get(Gap, Gap)
+	h := &hirschberg{s: s, open: open}
+	h.align(a, b, open, open)
+	return h.steps, s.score(a, b, h.steps)
+}
+
+// LocalLinear performs local alignment on a and b and finds the highest scoring
+// alignment. Returns the steps relating to a, ai and bi as the start positions of
+// the local alignment in a and b respectively, and the alignment score.
+// Time complexity is O(len(a)*len(b)), and space complexity is
+// O(len(a)+len(b)).
+//
+// Returns the same score as Local, but may return a different alignment if
+// several alignments have the highest score.
+//
+// Uses Hirschberg's algorithm, with Myers and Miller's extension to affine gaps.
+func LocalLinear(a, b []byte, m SubstitutionMatrix) (
+	steps []Step, ai, bi int, score float64) {
+	s := m.toArray()
+
+	// Find where the alignment ends, then where it starts.
+	aj, bj, score := localEnd(a, b, s, false)
+	if score <= 0 {
+		return nil, -1, -1, 0
+	}
+	ra, rb := reversed(a[:aj]), reversed(b[:bj])
+	ai, bi, _ = localEnd(ra, rb, s, true)
+	ai, bi = aj-ai, bj-bi
+
+	steps, _ = GlobalLinear(a[ai:aj], b[bi:bj], m)
+	return steps, ai, bi, s.score(a[ai:aj], b[bi:bj], steps)
+}
+
+// Holds the state of a linear-space alignment.
+type hirschberg struct {
+	s     *substitutionArray
+	open  float64
+	steps []Step // Output steps
+}
+
+// Appends to h.steps the highest scoring alignment of a and b.
+// tb and te are the scores of opening a gap with a deletion at the start and at
+// the end of the alignment, respectively. Each may be either the gap-open score,
+// or 0 if the gap continues a gap from outside the alignment.
+func (h *hirschberg) align(a, b []byte, tb, te float64) {
+	if len(a) == 0 {
+		for range b {
+			h.steps = append(h.steps, Insertion)
+		}
+		return
+	}
+	if len(b) == 0 {
+		for range a {
+			h.steps = append(h.steps, Deletion)
+		}
+		return
+	}
+	if len(a) == 1 {
+		h.alignSingle(a[0], b, tb, te)
+		return
+	}
+
+	// Find where the best alignment crosses the middle row.
+	mid := len(a) / 2
+	cc, dd := lastRow(a[:mid], b, h.s, h.open, tb)
+	rr, ss := lastRow(reversed(a[mid:]), reversed(b), h.s, h.open, te)
+	slices.Reverse(rr)
+	slices.Reverse(ss)
+	bmid, crossing, best := 0, false, negInf
+	for i := range cc {
+		if x := cc[i] + rr[i]; x > best {
+			bmid, crossing, best = i, false, x
+		}
+		// A deletion that crosses the middle row is opened only once.
+		if x := dd[i] + ss[i] - h.open; x > best {
+			bmid, crossing, best = i, true, x
+		}
+	}
+
+	if crossing {
+		h.align(a[:mid-1], b[:bmid], tb, 0)
+		h.steps = append(h.steps, Deletion, Deletion)
+		h.align(a[mid+1:], b[bmid:], 0, te)
+	} else {
+		h.align(a[:mid], b[:bmid], tb, h.open)
+		h.align(a[mid:], b[bmid:], h.open, te)
+	}
+}
+
+// Appends to h.steps the highest scoring alignment of a single character c and
+// b, where b is not empty. tb and te are the same as in align.
+func (h *hirschberg) alignSingle(c byte, b []byte, tb, te float64) {
+	// Scores of inserting parts of b.
+	prefix := make([]float64, len(b)+1)
+	for i, x := range b {
+		prefix[i+1] = prefix[i] + h.s.get(Gap, x)
+	}
+	insertion := func(from, to int) float64 {
+		if from == to {
+			return 0
+		}
+		return h.open + prefix[to] - prefix[from]
+	}
+
+	// Delete c.
+	best := max(tb, te) + h.s.get(c, Gap) + insertion(0, len(b))
+	bmatch := -1
+
+	// Match c with one of b's characters.
+	for i, x := range b {
+		score := insertion(0, i) + h.s.get(c, x) + insertion(i+1, len(b))
+		if score >= best {
+			best, bmatch = score, i
+		}
+	}
+
+	if bmatch == -1 {
+		if tb >= te {
+			h.steps = append(h.steps, Deletion)
+		}
+		for range b {
+			h.steps = append(h.steps, Insertion)
+		}
+		if tb < te {
+			h.steps = append(h.steps, Deletion)
+		}
+		return
+	}
+	for i := range b {
+		if i == bmatch {
+			h.steps = append(h.steps, Match)
+		} else {
+			h.steps = append(h.steps, Insertion)
+		}
+	}
+}
+
+// Returns the last row of the global Gotoh table of a and b, in linear space.
+// cc holds the best score of aligning a with each prefix of b, and dd holds the
+// best score of those that end with a deletion. tb is the score of opening a
+// gap with a deletion at the start of the alignment. a should not be empty.
+func lastRow(a, b []byte, s *substitutionArray, open, tb float64) (
+	cc, dd []float64) {
+	bn := len(b) + 1
+	prev, cur := make([]cellScores, bn), make([]cellScores, bn)
+	for ai := range len(a) + 1 {
+		for bi := range bn {
+			cur[bi] = cellScores{negInf, negInf, negInf, negInf}
+			if ai > 0 && bi > 0 {
+				p := prev[bi-1]
+				x := max(p[Match], p[Deletion], p[Insertion])
+				if ai == 1 && bi == 1 { // Start
+					x = 0
+				}
+				cur[bi][Match] = x + s.get(a[ai-1], b[bi-1])
+			}
+			if ai > 0 {
+				p := prev[bi]
+				x := max(p[Match]+open, p[Deletion], p[Insertion]+open)
+				if ai == 1 && bi == 0 { // Start
+					x = tb
+				}
+				cur[bi][Deletion] = x + s.get(a[ai-1], Gap)
+			}
+			if bi > 0 {
+				p := cur[bi-1]
+				x := max(p[Match]+open, p[Deletion]+open, p[Insertion])
+				if ai == 0 && bi == 1 { // Start
+					x = open
+				}
+				cur[bi][Insertion] = x + s.get(Gap, b[bi-1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	cc, dd = make([]float64, bn), make([]float64, bn)
+	for bi, p := range prev {
+		cc[bi] = max(p[Match], p[Deletion], p[Insertion])
+		dd[bi] = p[Deletion]
+	}
+	return cc, dd
+}
+
+// Finds the end of the best local alignment of a and b, in linear space.
+// Returns the end positions in a and b (exclusive), and the alignment score.
+// If anchored is true, alignments must start at the beginning of a and b.
+// Alignments start and end with a match.
+func localEnd(a, b []byte, s *substitutionArray, anchored bool) (
+	aj, bj int, score float64) {
+	bn := len(b) + 1
+	open := s.get(Gap, Gap)
+	prev, cur := make([]cellScores, bn), make([]cellScores, bn)
+	score = negInf
+	for ai := range len(a) + 1 {
+		for bi := range bn {
+			cur[bi] = cellScores{negInf, negInf, negInf, negInf}
+			if ai > 0 && bi > 0 {
+				p := prev[bi-1]
+				x := max(p[Match], p[Deletion], p[Insertion])
+				if anchored && ai == 1 && bi == 1 || !anchored && x <= 0 {
+					x = 0 // Start
+				}
+				cur[bi][Match] = x + s.get(a[ai-1], b[bi-1])
+				if cur[bi][Match] > score {
+					aj, bj, score = ai, bi, cur[bi][Match]
+				}
+			}
+			if ai > 0 {
+				p := prev[bi]
+				cur[bi][Deletion] = max(p[Match]+open, p[Deletion],
+					p[Insertion]+open) + s.get(a[ai-1], Gap)
+			}
+			if bi > 0 {
+				p := cur[bi-1]
+				cur[bi][Insertion] = max(p[Match]+open, p[Deletion]+open,
+					p[Insertion]) + s.get(Gap, b[bi-1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return aj, bj, score
+}
+
+// Returns a reversed copy of a.
+func reversed(a []byte) []byte {
+	a = slices.Clone(a)
+	slices.Reverse(a)
+	return a
+}
@@ -0,0 +1,100 @@
+package align
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestGlobalLinear(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 3,
+		{'b', 'b'}: 2,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -2,
+		{'b', 'c'}: 0,
+		{'a', Gap}: -1,
+		{'b', Gap}: -2,
+		{'c', Gap}: -1,
+		{Gap, Gap}: -3,
+	}.Symmetrical()
+	for range 1000 {
+		a, b := randomSeq(rand.IntN(20), "abc"), randomSeq(rand.IntN(20), "abc")
+		_, want := Global(a, b, m)
+		steps, got := GlobalLinear(a, b, m)
+		if got != want {
+			t.Fatalf("GlobalLinear(%q,%q) score=%v, want %v", a, b, got, want)
+		}
+		if an, bn := stepsLen(steps); an != len(a) || bn != len(b) {
+			t.Fatalf("GlobalLinear(%q,%q)=%v, covers lengths %v,%v",
+				a, b, steps, an, bn)
+		}
+		if s := scoreSteps(a, b, steps, m); s != got {
+			t.Fatalf("GlobalLinear(%q,%q)=%v with score %v, but steps score %v",
+				a, b, steps, got, s)
+		}
+	}
+}
+
+func TestLocalLinear(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 3,
+		{'b', 'b'}: 2,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -2,
+		{'b', 'c'}: 0,
+		{'a', Gap}: -1,
+		{'b', Gap}: -2,
+		{'c', Gap}: -1,
+		{Gap, Gap}: -2,
+	}.Symmetrical()
+	for range 1000 {
+		a, b := randomSeq(rand.IntN(20), "abc"), randomSeq(rand.IntN(20), "abc")
+		_, _, _, want := Local(a, b, m)
+		steps, ai, bi, got := LocalLinear(a, b, m)
+		if got != want {
+			t.Fatalf("LocalLinear(%q,%q) score=%v, want %v", a, b, got, want)
+		}
+		if got == 0 {
+			if steps != nil || ai != -1 || bi != -1 {
+				t.Fatalf("LocalLinear(%q,%q)=%v,%v,%v, want nil,-1,-1",
+					a, b, steps, ai, bi)
+			}
+			continue
+		}
+		if s := scoreSteps(a[ai:], b[bi:], steps, m); s != got {
+			t.Fatalf("LocalLinear(%q,%q)=%v,%v,%v with score %v, "+
+				"but steps score %v", a, b, steps, ai, bi, got, s)
+		}
+	}
+}
+
+// Returns the number of characters of a and b that are covered by the steps.
+func stepsLen(steps []Step) (an, bn int) {
+	for _, step := range steps {
+		if step != Insertion {
+			an++
+		}
+		if step != Deletion {
+			bn++
+		}
+	}
+	return an, bn
+}
+
+func BenchmarkGlobal(b *testing.B) {
+	x, y := randomSeq(1000, "ACDEFGHIKLMNPQRSTVWY"),
+		randomSeq(1000, "ACDEFGHIKLMNPQRSTVWY")
+	for b.Loop() {
+		Global(x, y, BLOSUM62)
+	}
+}
+
+func BenchmarkGlobalLinear(b *testing.B) {
+	x, y := randomSeq(1000, "ACDEFGHIKLMNPQRSTVWY"),
+		randomSeq(1000, "ACDEFGHIKLMNPQRSTVWY")
+	for b.Loop() {
+		GlobalLinear(x, y, BLOSUM62)
+	}
+}
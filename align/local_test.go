@@ -1,6 +1,7 @@
 package align
 
 import (
+	"math/rand/v2"
 	"reflect"
 	"testing"
 )
@@ -47,3 +48,45 @@ func TestLocal(t *testing.T) {
 		}
 	}
 }
+
+func TestLocal_exhaustive(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 3,
+		{'b', 'b'}: 2,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -2,
+		{'b', 'c'}: 0,
+		{'a', Gap}: -1,
+		{'b', Gap}: -2,
+		{'c', Gap}: -1,
+		{Gap, Gap}: -2,
+	}.Symmetrical()
+	for range 100 {
+		a, b := randomSeq(rand.IntN(6), "abc"), randomSeq(rand.IntN(6), "abc")
+		want := 0.0
+		for ai := range len(a) {
+			for aj := ai; aj <= len(a); aj++ {
+				for bi := range len(b) {
+					for bj := bi; bj <= len(b); bj++ {
+						for _, steps := range allAlignments(aj-ai, bj-bi) {
+							want = max(want,
+								scoreSteps(a[ai:aj], b[bi:bj], steps, m))
+						}
+					}
+				}
+			}
+		}
+		steps, ai, bi, got := Local(a, b, m)
+		if got != want {
+			t.Fatalf("Local(%q,%q) score=%v, want %v", a, b, got, want)
+		}
+		if got == 0 {
+			continue
+		}
+		if s := scoreSteps(a[ai:], b[bi:], steps, m); s != got {
+			t.Fatalf("Local(%q,%q)=%v,%v,%v with score %v, but steps score %v",
+				a, b, steps, ai, bi, got, s)
+		}
+	}
+}
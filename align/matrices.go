@@ -8,4 +8,8 @@ var (
 	BLOSUM80    SubstitutionMatrix
 	BLOSUM62    SubstitutionMatrix
 	BLOSUM45    SubstitutionMatrix
+
+	// Nucleotides with IUPAC ambiguity codes (NUC.4.4), as in EMBOSS.
+	// Gap scores are EMBOSS's defaults: open 10 and extend 0.5.
+	EDNAFULL SubstitutionMatrix
 )
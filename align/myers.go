@@ -0,0 +1,145 @@
+// Bit-parallel edit distance (Myers).
+
+package align
+
+import "iter"
+
+// EditDistance returns the Levenshtein distance between a and b, which is the
+// minimal number of substitutions, insertions and deletions that turn a into b.
+// Equivalent to the negated score of Global with the Levenshtein matrix, but
+// much faster and without allocating a table.
+// Time complexity is O(len(a)*len(b)/64), and space complexity is
+// O(min(len(a),len(b))).
+//
+// Uses Myers' bit-parallel algorithm.
+//
+// Myers G. A fast bit-vector algorithm for approximate string matching based
+// on dynamic programming. Journal of the ACM. 1999.
+func EditDistance(a, b []byte) int {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(a) == 0 {
+		return len(b)
+	}
+	m := newMyers(a)
+	for _, c := range b {
+		m.advance(c, 1)
+	}
+	return m.score
+}
+
+// EditSearch returns the end positions in text of the occurrences of pattern
+// with at most k errors (substitutions, insertions and deletions), along with
+// the number of errors. An end position e means that an occurrence ends at
+// text[e-1], so text[:e] has a suffix that matches pattern. Overlapping
+// occurrences are all reported, in increasing order of end position. End
+// position 0 is reported if len(pattern) <= k, as the empty prefix of text
+// matches pattern by deleting all of it.
+// Time complexity is O(len(pattern)*len(text)/64), and space complexity is
+// O(len(pattern)).
+//
+// Uses Myers' bit-parallel algorithm.
+func EditSearch(pattern, text []byte, k int) iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		if len(pattern) == 0 {
+			for e := range len(text) + 1 {
+				if !yield(e, 0) {
+					return
+				}
+			}
+			return
+		}
+		m := newMyers(pattern)
+		if m.score <= k {
+			if !yield(0, m.score) {
+				return
+			}
+		}
+		for i, c := range text {
+			m.advance(c, 0)
+			if m.score <= k {
+				if !yield(i+1, m.score) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// State of Myers' algorithm: a column of the dynamic-programming table,
+// encoded as vertical deltas in 64-bit blocks.
+type myers struct {
+	peq   []uint64 // Match bits of each character, 256 groups of blocks
+	pv    []uint64 // Positive vertical deltas
+	mv    []uint64 // Negative vertical deltas
+	last  uint64   // Bit of the last pattern row in the last block
+	score int      // Value of the last row in the current column
+}
+
+// Returns the initial state for the given pattern. pattern should not be
+// empty.
+func newMyers(pattern []byte) *myers {
+	n := (len(pattern) + 63) / 64
+	m := &myers{
+		peq:   make([]uint64, 256*n),
+		pv:    make([]uint64, n),
+		mv:    make([]uint64, n),
+		last:  1 << ((len(pattern) - 1) % 64),
+		score: len(pattern),
+	}
+	for i, c := range pattern {
+		m.peq[int(c)*n+i/64] |= 1 << (i % 64)
+	}
+	for i := range m.pv {
+		m.pv[i] = ^uint64(0)
+	}
+	return m
+}
+
+// Advances the state by one column for text character c. hin is the
+// difference between the first row of the new column and of the previous one:
+// 1 for edit distance, 0 for search.
+func (m *myers) advance(c byte, hin int) {
+	n := len(m.pv)
+	peq := m.peq[int(c)*n : int(c)*n+n]
+	for i := range n {
+		high := uint64(1) << 63
+		if i == n-1 {
+			high = m.last
+		}
+		hin = advanceBlock(&m.pv[i], &m.mv[i], peq[i], hin, high)
+	}
+	m.score += hin
+}
+
+// Advances a single block of vertical deltas, given the match bits eq and the
+// horizontal delta hin that enters its top. Returns the horizontal delta that
+// exits the block at the bit high.
+func advanceBlock(pv, mv *uint64, eq uint64, hin int, high uint64) int {
+	xv := eq | *mv
+	if hin < 0 {
+		eq |= 1
+	}
+	xh := (((eq & *pv) + *pv) ^ *pv) | eq
+	ph := *mv | ^(xh | *pv)
+	mh := *pv & xh
+
+	hout := 0
+	if ph&high != 0 {
+		hout = 1
+	} else if mh&high != 0 {
+		hout = -1
+	}
+
+	ph <<= 1
+	mh <<= 1
+	if hin < 0 {
+		mh |= 1
+	} else if hin > 0 {
+		ph |= 1
+	}
+	*pv = mh | ^(xv | ph)
+	*mv = ph & xv
+	return hout
+}
@@ -0,0 +1,114 @@
+package align
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"GATTACA", "GATTACA", 0},
+	}
+	for _, test := range tests {
+		if got := EditDistance([]byte(test.a), []byte(test.b)); got != test.want {
+			t.Errorf("EditDistance(%q,%q)=%v, want %v",
+				test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestEditDistance_random(t *testing.T) {
+	for range 300 {
+		// Lengths cross block boundaries.
+		a := randomSeq(rand.IntN(200), "ACGT")
+		b := randomSeq(rand.IntN(200), "ACGT")
+		_, score := Global(a, b, Levenshtein)
+		if got := EditDistance(a, b); got != int(-score) {
+			t.Fatalf("EditDistance(%q,%q)=%v, want %v", a, b, got, -score)
+		}
+	}
+}
+
+func TestEditSearch(t *testing.T) {
+	text := []byte("xxabcxxabxcxxbc")
+	var got [][2]int
+	for e, d := range EditSearch([]byte("abc"), text, 1) {
+		got = append(got, [2]int{e, d})
+	}
+	want := [][2]int{{4, 1}, {5, 0}, {6, 1}, {9, 1}, {10, 1}, {11, 1}, {15, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EditSearch(\"abc\",%q,1)=%v, want %v", text, got, want)
+	}
+
+	// A pattern within k errors also ends at position 0.
+	got = nil
+	for e, d := range EditSearch([]byte("ab"), []byte("xb"), 2) {
+		got = append(got, [2]int{e, d})
+	}
+	want = [][2]int{{0, 2}, {1, 2}, {2, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EditSearch(\"ab\",\"xb\",2)=%v, want %v", got, want)
+	}
+}
+
+func TestEditSearch_random(t *testing.T) {
+	for range 300 {
+		pattern := randomSeq(rand.IntN(150), "ACGT")
+		text := randomSeq(rand.IntN(300), "ACGT")
+		k := rand.IntN(len(pattern)/2 + 1)
+		want := map[int]int{}
+		for e, d := range editSearchSlow(pattern, text) {
+			if d <= k {
+				want[e] = d
+			}
+		}
+		got := map[int]int{}
+		for e, d := range EditSearch(pattern, text, k) {
+			got[e] = d
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("EditSearch(%q,%q,%v)=%v, want %v",
+				pattern, text, k, got, want)
+		}
+	}
+}
+
+// Returns the minimal edit distance of pattern with a suffix of text[:e], for
+// every end position e.
+func editSearchSlow(pattern, text []byte) []int {
+	col := make([]int, len(pattern)+1)
+	for i := range col {
+		col[i] = i
+	}
+	result := []int{col[len(pattern)]}
+	for _, c := range text {
+		diag := col[0]
+		col[0] = 0
+		for i := 1; i <= len(pattern); i++ {
+			sub := diag
+			if pattern[i-1] != c {
+				sub++
+			}
+			diag = col[i]
+			col[i] = min(sub, col[i]+1, col[i-1]+1)
+		}
+		result = append(result, col[len(pattern)])
+	}
+	return result
+}
+
+func BenchmarkEditDistance(b *testing.B) {
+	x, y := randomSeq(1000, "ACGT"), randomSeq(1000, "ACGT")
+	for b.Loop() {
+		EditDistance(x, y)
+	}
+}
@@ -0,0 +1,60 @@
+// Nucleotide substitution matrices.
+
+package align
+
+import "strings"
+
+// Bases that each IUPAC nucleotide code represents.
+var iupacBases = map[byte]string{
+	'A': "A", 'C': "C", 'G': "G", 'T': "T",
+	'R': "AG", 'Y': "CT", 'S': "CG", 'W': "AT", 'K': "GT", 'M': "AC",
+	'B': "CGT", 'D': "AGT", 'H': "ACT", 'V': "ACG",
+	'N': "ACGT",
+}
+
+// NucleotideMatrix returns a substitution matrix for nucleotides, where
+// identical bases score match, transitions (A<->G, C<->T) score transition,
+// and transversions score transversion. For a simple match/mismatch scheme,
+// use the same value for transition and transversion. Every character scores
+// gap when aligned with a gap, with 0 for opening a gap. For affine gaps, use
+// WithGaps on the result.
+//
+// The matrix covers upper and lower case A, C, G, T and the IUPAC ambiguity
+// codes (R, Y, S, W, K, M, B, D, H, V and N). U scores as T. Scores of
+// ambiguity codes are the averages of the scores of the bases they represent.
+func NucleotideMatrix(match, transition, transversion,
+	gap float64) SubstitutionMatrix {
+	bases := map[byte]string{'U': "T", 'u': "T"}
+	for c, b := range iupacBases {
+		bases[c] = b
+		bases[c+'a'-'A'] = b
+	}
+
+	score := func(a, b byte) float64 {
+		switch {
+		case a == b:
+			return match
+		case strings.IndexByte("AG", a) != -1 ==
+			(strings.IndexByte("AG", b) != -1):
+			return transition
+		default:
+			return transversion
+		}
+	}
+
+	m := SubstitutionMatrix{{Gap, Gap}: 0}
+	for a, aa := range bases {
+		m[[2]byte{a, Gap}] = gap
+		m[[2]byte{Gap, a}] = gap
+		for b, bb := range bases {
+			sum := 0.0
+			for i := range len(aa) {
+				for j := range len(bb) {
+					sum += score(aa[i], bb[j])
+				}
+			}
+			m[[2]byte{a, b}] = sum / float64(len(aa)*len(bb))
+		}
+	}
+	return m
+}
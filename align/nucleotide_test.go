@@ -0,0 +1,78 @@
+package align
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNucleotideMatrix(t *testing.T) {
+	m := NucleotideMatrix(2, -1, -3, -4)
+	tests := []struct {
+		a, b byte
+		want float64
+	}{
+		{'A', 'A', 2},
+		{'a', 'A', 2},
+		{'A', 'G', -1},
+		{'C', 't', -1},
+		{'A', 'C', -3},
+		{'G', 'T', -3},
+		{'U', 'T', 2},
+		{'N', 'A', -1.25},
+		{'R', 'A', 0.5},
+		{'R', 'Y', -3},
+		{'A', Gap, -4},
+		{Gap, 'n', -4},
+		{Gap, Gap, 0},
+	}
+	for _, test := range tests {
+		if got := m[[2]byte{test.a, test.b}]; got != test.want {
+			t.Errorf("NucleotideMatrix[%s,%s]=%v, want %v",
+				charOrGap(test.a), charOrGap(test.b), got, test.want)
+		}
+	}
+	if sym := m.Symmetrical(); !reflect.DeepEqual(sym, m) {
+		t.Errorf("NucleotideMatrix is not symmetrical")
+	}
+	// 16 codes in 2 cases, their pairs, their gaps and the gap pair.
+	if want := 32*32 + 64 + 1; len(m) != want {
+		t.Errorf("len(NucleotideMatrix)=%v, want %v", len(m), want)
+	}
+	for k := range m {
+		for _, c := range k {
+			if c != Gap && !strings.ContainsRune(
+				"ACGTURYSWKMBDHVNacgturyswkmbdhvn", rune(c)) {
+				t.Fatalf("NucleotideMatrix has unexpected pair %q", k)
+			}
+		}
+	}
+}
+
+func TestEDNAFULL(t *testing.T) {
+	tests := []struct {
+		a, b byte
+		want float64
+	}{
+		{'A', 'A', 5},
+		{'A', 'T', -4},
+		{'N', 'N', -1},
+		{'A', 'R', 1},
+		{'U', 'T', 5},
+		{'A', Gap, -0.5},
+		{Gap, Gap, -9.5},
+	}
+	for _, test := range tests {
+		if got := EDNAFULL[[2]byte{test.a, test.b}]; got != test.want {
+			t.Errorf("EDNAFULL[%s,%s]=%v, want %v",
+				charOrGap(test.a), charOrGap(test.b), got, test.want)
+		}
+	}
+	if sym := EDNAFULL.Symmetrical(); !reflect.DeepEqual(sym, EDNAFULL) {
+		t.Errorf("EDNAFULL is not symmetrical")
+	}
+	if _, score := Global([]byte("GATTACA"), []byte("GATACA"),
+		EDNAFULL); score != 20 {
+		t.Errorf("Global(GATTACA,GATACA)=%v, want 20", score)
+	}
+}
@@ -0,0 +1,112 @@
+// Profile-profile alignment.
+
+package align
+
+import "fmt"
+
+// GlobalProfiles performs global alignment on the columns of two profiles,
+// and finds the highest scoring alignment. Profiles are given as aligned rows
+// of equal length, with GapByte as the gap character. Returns the steps
+// relating to the columns of a, and the alignment score.
+// Time complexity is O(len(a[0])*len(b[0])*k^2), where k is the number of
+// distinct characters in a column. Space complexity is O(len(a[0])*len(b[0])).
+//
+// Two columns score the average score of the pairs of their characters, where
+// pairs with a GapByte score 0. A column with a gap scores the average score
+// of its characters with Gap. Gap opening scores are those of m, as described
+// in the package documentation.
+func GlobalProfiles(a, b [][]byte, m SubstitutionMatrix) (
+	steps []Step, score float64) {
+	s := m.toArray()
+	colsA, colsB := profileColumns(a), profileColumns(b)
+
+	insertions := make([]float64, len(colsB))
+	for i, c := range colsB {
+		insertions[i] = c.gapScore(s, len(b), true)
+	}
+	open := s.get(Gap, Gap)
+	pos := make([]position, len(colsA)+1)
+	for i := range pos {
+		pos[i] = position{openDeletion: open, openInsertion: open,
+			char: -1, insertionB: insertions}
+		if i < len(colsA) {
+			ca := colsA[i]
+			pos[i].deletion = ca.gapScore(s, len(a), false)
+			pos[i].matchB = make([]float64, len(colsB))
+			for j, cb := range colsB {
+				pos[i].matchB[j] = ca.pairScore(cb, s) /
+					float64(len(a)*len(b))
+			}
+		}
+	}
+
+	trace, end, last, score := gotoh(pos, nil, 0, false, nil)
+	steps, _ = traceSteps(trace, len(colsB)+1, end, last)
+	return steps, score
+}
+
+// A profile column, as counts of its non-gap characters.
+type profileColumn struct {
+	chars  []byte
+	counts []float64
+}
+
+// Returns the columns of the given rows. Panics if there are no rows or if
+// they have different lengths.
+func profileColumns(rows [][]byte) []profileColumn {
+	if len(rows) == 0 {
+		panic("profile has no rows")
+	}
+	for i := range rows {
+		if len(rows[i]) != len(rows[0]) {
+			panic(fmt.Sprintf("row %v has length %v, want %v",
+				i, len(rows[i]), len(rows[0])))
+		}
+	}
+	cols := make([]profileColumn, len(rows[0]))
+	for i := range cols {
+		var counts [256]float64
+		for _, row := range rows {
+			c := row[i]
+			if c == GapByte {
+				continue
+			}
+			if counts[c] == 0 {
+				cols[i].chars = append(cols[i].chars, c)
+			}
+			counts[c]++
+		}
+		for _, c := range cols[i].chars {
+			cols[i].counts = append(cols[i].counts, counts[c])
+		}
+	}
+	return cols
+}
+
+// Returns the sum of the scores of the character pairs of columns c and d.
+func (c profileColumn) pairScore(d profileColumn,
+	s *substitutionArray) float64 {
+	sum := 0.0
+	for i, ca := range c.chars {
+		for j, cb := range d.chars {
+			sum += c.counts[i] * d.counts[j] * s.get(ca, cb)
+		}
+	}
+	return sum
+}
+
+// Returns the average score of the characters of c with gaps, where c has n
+// rows. If gapFirst is true, uses the scores of (Gap,c) pairs instead of
+// (c,Gap).
+func (c profileColumn) gapScore(s *substitutionArray, n int,
+	gapFirst bool) float64 {
+	sum := 0.0
+	for i, ch := range c.chars {
+		if gapFirst {
+			sum += c.counts[i] * s.get(Gap, ch)
+		} else {
+			sum += c.counts[i] * s.get(ch, Gap)
+		}
+	}
+	return sum / float64(n)
+}
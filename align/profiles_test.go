@@ -0,0 +1,74 @@
+package align
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+// Profiles of single sequences should behave like the sequences.
+func TestGlobalProfiles_single(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 3,
+		{'b', 'b'}: 2,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -2,
+		{'b', 'c'}: 0,
+		{'a', Gap}: -1,
+		{'b', Gap}: -2,
+		{'c', Gap}: -1,
+		{Gap, Gap}: -2,
+	}.Symmetrical()
+	for range 100 {
+		a := randomSeq(rand.IntN(10), "abc")
+		b := randomSeq(rand.IntN(10), "abc")
+		wantSteps, wantScore := Global(a, b, m)
+		gotSteps, gotScore := GlobalProfiles([][]byte{a}, [][]byte{b}, m)
+		if gotScore != wantScore || !reflect.DeepEqual(gotSteps, wantSteps) {
+			t.Fatalf("GlobalProfiles(%q,%q)=%v,%v, want %v,%v",
+				a, b, gotSteps, gotScore, wantSteps, wantScore)
+		}
+	}
+}
+
+func TestGlobalProfiles(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 4,
+		{'b', 'b'}: 4,
+		{'a', 'b'}: -4,
+		{'a', Gap}: -1,
+		{'b', Gap}: -1,
+		{Gap, Gap}: -2,
+	}.Symmetrical()
+	a := [][]byte{[]byte("ab"), []byte("a-")}
+	b := [][]byte{[]byte("a"), []byte("a")}
+	// Match: 4*2*2/4=4. Deletion of column 2: (-1+0)/2, with an opening of -2.
+	steps, score := GlobalProfiles(a, b, m)
+	want := []Step{Match, Deletion}
+	if !reflect.DeepEqual(steps, want) || score != 1.5 {
+		t.Errorf("GlobalProfiles(%q,%q)=%v,%v, want %v,%v",
+			a, b, steps, score, want, 1.5)
+	}
+}
+
+func TestGlobalProfiles_bad(t *testing.T) {
+	m := SubstitutionMatrix{{'a', 'a'}: 1, {'a', Gap}: -1, {Gap, 'a'}: -1,
+		{Gap, Gap}: -1}
+	inputs := [][2][][]byte{
+		{nil, {[]byte("a")}},
+		{{[]byte("a"), []byte("aa")}, {[]byte("a")}},
+		{{[]byte("b")}, {[]byte("a")}},
+	}
+	for _, input := range inputs {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("GlobalProfiles(%q,%q) did not panic",
+						input[0], input[1])
+				}
+			}()
+			GlobalProfiles(input[0], input[1], m)
+		}()
+	}
+}
@@ -0,0 +1,176 @@
+// Position-specific scoring.
+
+package align
+
+import (
+	"fmt"
+	"math"
+)
+
+// A PSSM is a position-specific scoring matrix. It represents a profile of a
+// sequence family, where each position has its own match and gap scores. A PSSM
+// can be aligned with sequences using GlobalPSSM and LocalPSSM, where it takes
+// the place of a.
+type PSSM []PSSMColumn
+
+// A PSSMColumn holds the scores of a single position in a PSSM. Gap scores are
+// as described in the package documentation, with a gap of length k scoring an
+// open score plus k extension scores.
+type PSSMColumn struct {
+	Match         map[byte]float64 // Scores of characters at this position
+	Deletion      float64          // Score of this position with a gap
+	DeletionOpen  float64          // Score of opening a gap at this position
+	Insertion     map[byte]float64 // Scores of characters inserted after it
+	InsertionOpen float64          // Score of opening a gap after it
+}
+
+// GapByte is the character that represents gaps in alignments that are given as
+// rows, such as the input of NewPSSM. Unlike Gap, which stands for gaps in
+// substitution matrices, it is a printable character.
+const GapByte = '-'
+
+// NewPSSM returns a profile of the given aligned rows, scored with m. Rows
+// should be of equal length, with GapByte as the gap character. Columns that
+// have only gaps are skipped.
+//
+// The match score of a character is its average score against the characters
+// in the column. Gap scores are those of m, multiplied by the fraction of rows
+// that have a character in the column, so gaps are cheaper where the family
+// has gaps. Characters that are missing a score with any of the characters in
+// the column are not included.
+//
+// Gribskov M, McLachlan AD, Eisenberg D. Profile analysis: detection of
+// distantly related proteins. PNAS. 1987.
+func NewPSSM(rows [][]byte, m SubstitutionMatrix) PSSM {
+	for i := range rows {
+		if len(rows[i]) != len(rows[0]) {
+			panic(fmt.Sprintf("row %v has length %v, want %v",
+				i, len(rows[i]), len(rows[0])))
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	s := m.toArray()
+	open := s.get(Gap, Gap)
+	var chars []byte // All characters that have scores in m.
+	for c := range 256 {
+		for a := range 256 {
+			if a != Gap && c != Gap && !math.IsNaN(s[a][c]) {
+				chars = append(chars, byte(c))
+				break
+			}
+		}
+	}
+
+	var p PSSM
+	for col := range rows[0] {
+		var counts [256]int
+		n := 0
+		for _, row := range rows {
+			if row[col] != GapByte {
+				counts[row[col]]++
+				n++
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		w := float64(n) / float64(len(rows))
+
+		pc := PSSMColumn{
+			Match:         map[byte]float64{},
+			DeletionOpen:  open * w,
+			Insertion:     map[byte]float64{},
+			InsertionOpen: open * w,
+		}
+		for a, count := range counts {
+			if count > 0 {
+				pc.Deletion += s.get(byte(a), Gap) * float64(count)
+			}
+		}
+		pc.Deletion /= float64(len(rows))
+	chars:
+		for _, c := range chars {
+			sum := 0.0
+			for a, count := range counts {
+				if count == 0 {
+					continue
+				}
+				if math.IsNaN(s[a][c]) {
+					continue chars
+				}
+				sum += s[a][c] * float64(count)
+			}
+			pc.Match[c] = sum / float64(n)
+			if x := s[Gap][c]; !math.IsNaN(x) {
+				pc.Insertion[c] = x * w
+			}
+		}
+		p = append(p, pc)
+	}
+	return p
+}
+
+// Returns the positions of p, for use with gotoh.
+func (p PSSM) positions() []position {
+	toArray := func(m map[byte]float64) *[256]float64 {
+		a := &[256]float64{}
+		for i := range a {
+			a[i] = math.NaN()
+		}
+		for k, v := range m {
+			a[k] = v
+		}
+		return a
+	}
+
+	pos := make([]position, len(p)+1)
+	for i := range pos {
+		// Insertions before position i are after position i-1.
+		var ins PSSMColumn
+		if len(p) > 0 {
+			ins = p[max(i-1, 0)]
+		}
+		pos[i] = position{
+			insertion:     toArray(ins.Insertion),
+			openInsertion: ins.InsertionOpen,
+			char:          -1,
+		}
+		if i < len(p) {
+			pos[i].match = toArray(p[i].Match)
+			pos[i].deletion = p[i].Deletion
+			pos[i].openDeletion = p[i].DeletionOpen
+		}
+	}
+	return pos
+}
+
+// GlobalPSSM performs global alignment on the profile p and sequence b, and
+// finds the highest scoring alignment. Returns the steps relating to p, and the
+// alignment score.
+// Time and space complexities are O(len(p)*len(b)).
+//
+// Characters inserted before the first position of p use the scores of the
+// first position.
+func GlobalPSSM(p PSSM, b []byte) (steps []Step, score float64) {
+	trace, end, last, score := gotoh(p.positions(), b, 0, false, nil)
+	steps, _ = traceSteps(trace, len(b)+1, end, last)
+	return steps, score
+}
+
+// LocalPSSM performs local alignment on the profile p and sequence b, and finds
+// the highest scoring alignment. Returns the steps relating to p, pi and bi as
+// the start positions of the local alignment in p and b respectively, and the
+// alignment score.
+// Time and space complexities are O(len(p)*len(b)).
+func LocalPSSM(p PSSM, b []byte) (steps []Step, pi, bi int, score float64) {
+	bn := len(b) + 1
+	trace, end, last, score := gotoh(p.positions(), b, 0, true, nil)
+	if score <= 0 {
+		return nil, -1, -1, 0
+	}
+	steps, start := traceSteps(trace, bn, end, last)
+	return steps, start / bn, start % bn, score
+}
@@ -0,0 +1,111 @@
+package align
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestNewPSSM(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 2,
+		{'b', 'b'}: 4,
+		{'a', 'b'}: -2,
+		{'a', Gap}: -1,
+		{'b', Gap}: -3,
+		{Gap, Gap}: -4,
+	}.Symmetrical()
+	rows := [][]byte{
+		[]byte("aa-"),
+		[]byte("ab-"),
+		[]byte("-b-"),
+		[]byte("-b-"),
+	}
+	want := PSSM{
+		{
+			Match:         map[byte]float64{'a': 2, 'b': -2},
+			Deletion:      -0.5,
+			DeletionOpen:  -2,
+			Insertion:     map[byte]float64{'a': -0.5, 'b': -1.5},
+			InsertionOpen: -2,
+		},
+		{
+			Match:         map[byte]float64{'a': -1, 'b': 2.5},
+			Deletion:      -2.5,
+			DeletionOpen:  -4,
+			Insertion:     map[byte]float64{'a': -1, 'b': -3},
+			InsertionOpen: -4,
+		},
+	}
+	if got := NewPSSM(rows, m); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewPSSM(%q)=%v, want %v", rows, got, want)
+	}
+}
+
+// A profile of a single sequence should behave like the sequence.
+func TestPSSM_single(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 3,
+		{'b', 'b'}: 2,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -2,
+		{'b', 'c'}: 0,
+		{'a', Gap}: -1,
+		{'b', Gap}: -2,
+		{'c', Gap}: -1,
+		{Gap, Gap}: -2,
+	}.Symmetrical()
+	for range 100 {
+		a := randomSeq(rand.IntN(10)+1, "abc")
+		b := randomSeq(rand.IntN(10), "abc")
+		p := NewPSSM([][]byte{a}, m)
+
+		wantSteps, wantScore := Global(a, b, m)
+		gotSteps, gotScore := GlobalPSSM(p, b)
+		if gotScore != wantScore || !reflect.DeepEqual(gotSteps, wantSteps) {
+			t.Fatalf("GlobalPSSM(%q,%q)=%v,%v, want %v,%v",
+				a, b, gotSteps, gotScore, wantSteps, wantScore)
+		}
+
+		wantSteps, wantAI, wantBI, wantScore := Local(a, b, m)
+		gotSteps, gotAI, gotBI, gotScore := LocalPSSM(p, b)
+		if gotScore != wantScore || !reflect.DeepEqual(gotSteps, wantSteps) ||
+			gotAI != wantAI || gotBI != wantBI {
+			t.Fatalf("LocalPSSM(%q,%q)=%v,%v,%v,%v, want %v,%v,%v,%v",
+				a, b, gotSteps, gotAI, gotBI, gotScore,
+				wantSteps, wantAI, wantBI, wantScore)
+		}
+	}
+}
+
+func TestGlobalPSSM(t *testing.T) {
+	col := func(del, delOpen float64) PSSMColumn {
+		return PSSMColumn{
+			Match:         map[byte]float64{'a': 1},
+			Deletion:      del,
+			DeletionOpen:  delOpen,
+			Insertion:     map[byte]float64{'a': -1},
+			InsertionOpen: -5,
+		}
+	}
+	// Deleting the middle positions is cheap.
+	p := PSSM{col(-5, -5), col(0, -1), col(0, 0), col(-5, -5)}
+	steps, score := GlobalPSSM(p, []byte("aa"))
+	want := []Step{Match, Deletion, Deletion, Match}
+	if !reflect.DeepEqual(steps, want) || score != 1 {
+		t.Errorf("GlobalPSSM(%v,\"aa\")=%v,%v, want %v,%v",
+			p, steps, score, want, 1)
+	}
+}
+
+func TestGlobalPSSM_missing(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("GlobalPSSM with a missing character did not panic")
+		}
+	}()
+	p := PSSM{{Match: map[byte]float64{'a': 1},
+		Insertion: map[byte]float64{'a': -1, 'b': -1}}}
+	GlobalPSSM(p, []byte("b"))
+}
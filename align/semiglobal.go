@@ -0,0 +1,53 @@
+package align
+
+// Ends determines which ends of the input sequences may be skipped at no cost in
+// a semi-global alignment. Values can be combined using the | operator.
+type Ends byte
+
+// Possible values of Ends.
+const (
+	FreeStartA Ends = 1 << iota // A prefix of a may be left unaligned.
+	FreeEndA                    // A suffix of a may be left unaligned.
+	FreeStartB                  // A prefix of b may be left unaligned.
+	FreeEndB                    // A suffix of b may be left unaligned.
+)
+
+// SemiGlobal performs semi-global alignment on a and b and finds the highest
+// scoring alignment, where the ends specified by free may be left unaligned at
+// no cost. Returns the steps relating to a, ai and bi as the start positions of
+// the alignment in a and b respectively, and the alignment score.
+// Time and space complexities are O(len(a)*len(b)).
+//
+// For example, FreeStartB|FreeEndB fits all of a into a part of b, such as a read
+// into a reference. FreeStartA|FreeEndB aligns a suffix of a with a prefix of b,
+// such as overlapping reads in an assembly. A value of 0 is equivalent to
+// Global.
+func SemiGlobal(a, b []byte, m SubstitutionMatrix, free Ends) (
+	steps []Step, ai, bi int, score float64) {
+	return NewSemiGlobalAligner(a, m, free).Align(b)
+}
+
+// A SemiGlobalAligner aligns a fixed sequence with many others, as in
+// SemiGlobal. It prepares the scores of the fixed sequence once, rather than
+// for each alignment.
+type SemiGlobalAligner struct {
+	pos  []position
+	free Ends
+}
+
+// NewSemiGlobalAligner returns an aligner of a with other sequences, with the
+// given matrix and free ends.
+func NewSemiGlobalAligner(a []byte, m SubstitutionMatrix,
+	free Ends) *SemiGlobalAligner {
+	return &SemiGlobalAligner{seqPositions(a, m.toArray()), free}
+}
+
+// Align returns the highest scoring alignment of the aligner's sequence (a)
+// with b, as returned by SemiGlobal.
+func (s *SemiGlobalAligner) Align(b []byte) (
+	steps []Step, ai, bi int, score float64) {
+	bn := len(b) + 1
+	trace, end, last, score := gotoh(s.pos, b, s.free, false, nil)
+	steps, start := traceSteps(trace, bn, end, last)
+	return steps, start / bn, start % bn, score
+}
@@ -0,0 +1,121 @@
+package align
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestSemiGlobal(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 1,
+		{'b', 'b'}: 1,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -1,
+		{'b', 'c'}: -1,
+	}.Symmetrical().WithGaps(-1, -1)
+	tests := []struct {
+		a, b           string
+		free           Ends
+		want           []Step
+		wantAI, wantBI int
+		wantScore      float64
+	}{
+		{"abc", "abc", 0, []Step{Match, Match, Match}, 0, 0, 3},
+		{"abc", "ccabcaa", FreeStartB | FreeEndB,
+			[]Step{Match, Match, Match}, 0, 2, 3},
+		{"abcab", "cabcc", FreeStartA | FreeEndB,
+			[]Step{Match, Match, Match}, 2, 0, 3},
+		{"cabcc", "abcab", FreeStartA | FreeEndB,
+			[]Step{Match, Match, Match, Match}, 1, 0, 2},
+		{"cabcc", "abcab", FreeStartB | FreeEndA,
+			[]Step{Match, Match, Match}, 0, 2, 3},
+		{"aaccbb", "aabb", FreeStartA | FreeEndA | FreeStartB | FreeEndB,
+			[]Step{Match, Match}, 0, 0, 2},
+	}
+	for _, test := range tests {
+		got, ai, bi, gotScore := SemiGlobal(
+			[]byte(test.a), []byte(test.b), m, test.free)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SemiGlobal(%q,%q,%v)=%v, want %v",
+				test.a, test.b, test.free, got, test.want)
+		}
+		if ai != test.wantAI || bi != test.wantBI {
+			t.Errorf("SemiGlobal(%q,%q,%v) ai,bi=%v,%v, want %v,%v",
+				test.a, test.b, test.free, ai, bi, test.wantAI, test.wantBI)
+		}
+		if gotScore != test.wantScore {
+			t.Errorf("SemiGlobal(%q,%q,%v) score=%v, want %v",
+				test.a, test.b, test.free, gotScore, test.wantScore)
+		}
+	}
+}
+
+func TestSemiGlobal_exhaustive(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 3,
+		{'b', 'b'}: 2,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -2,
+		{'b', 'c'}: 0,
+		{'a', Gap}: -1,
+		{'b', Gap}: -2,
+		{'c', Gap}: -1,
+		{Gap, Gap}: -2,
+	}.Symmetrical()
+	// Returns the possible start and end positions of a sequence.
+	ranges := func(n int, start, end bool) [][2]int {
+		var result [][2]int
+		for i := range n + 1 {
+			for j := i; j <= n; j++ {
+				if (i == 0 || start) && (j == n || end) {
+					result = append(result, [2]int{i, j})
+				}
+			}
+		}
+		return result
+	}
+	for range 200 {
+		a, b := randomSeq(rand.IntN(6), "abc"), randomSeq(rand.IntN(6), "abc")
+		free := Ends(rand.IntN(16))
+		want := negInf
+		for _, ra := range ranges(len(a), free&FreeStartA != 0,
+			free&FreeEndA != 0) {
+			for _, rb := range ranges(len(b), free&FreeStartB != 0,
+				free&FreeEndB != 0) {
+				for _, steps := range allAlignments(ra[1]-ra[0], rb[1]-rb[0]) {
+					want = max(want, scoreSteps(
+						a[ra[0]:ra[1]], b[rb[0]:rb[1]], steps, m))
+				}
+			}
+		}
+		steps, ai, bi, got := SemiGlobal(a, b, m, free)
+		if got != want {
+			t.Fatalf("SemiGlobal(%q,%q,%v) score=%v, want %v",
+				a, b, free, got, want)
+		}
+		if s := scoreSteps(a[ai:], b[bi:], steps, m); s != got {
+			t.Fatalf("SemiGlobal(%q,%q,%v)=%v,%v,%v with score %v, "+
+				"but steps score %v", a, b, free, steps, ai, bi, got, s)
+		}
+	}
+}
+
+func TestSemiGlobalAligner(t *testing.T) {
+	m := NucleotideMatrix(2, -1, -1, 0).WithGaps(-3, -2)
+	a := randomSeq(20, "ACGT")
+	free := FreeStartB | FreeEndB | FreeEndA
+	al := NewSemiGlobalAligner(a, m, free)
+	for range 50 {
+		b := randomSeq(rand.IntN(40), "ACGT")
+		wantSteps, wantAI, wantBI, wantScore := SemiGlobal(a, b, m, free)
+		steps, ai, bi, score := al.Align(b)
+		if score != wantScore || ai != wantAI || bi != wantBI ||
+			!reflect.DeepEqual(steps, wantSteps) {
+			t.Fatalf("Align(%q)=%v,%v,%v,%v, want %v,%v,%v,%v", b, steps, ai,
+				bi, score, wantSteps, wantAI, wantBI, wantScore)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Striped Smith-Waterman (Farrar).
+
+package align
+
+import (
+	"fmt"
+	"math"
+)
+
+// Number of lanes in a vector.
+const lanes = 8
+
+// A vector of packed integer lanes. Operations on vectors are written as
+// simple loops, which the compiler can optimize.
+type vec [lanes]int32
+
+// Minimal value for scores, allowing some additions without overflowing.
+const vecNegInf = math.MinInt32 / 2
+
+// Score of padding positions in the query. Keeps them from contributing to the
+// best score.
+const vecPadding = -1 << 20
+
+// A QueryProfile is a precomputed representation of a query sequence and a
+// substitution matrix, for fast calculation of local alignment scores against
+// many sequences.
+//
+// Uses Farrar's striped Smith-Waterman, where the query is split into
+// interleaved segments that are processed in parallel.
+//
+// Farrar M. Striped Smith-Waterman speeds database searches six times over
+// other SIMD implementations. Bioinformatics. 2007.
+type QueryProfile struct {
+	a      []byte
+	m      SubstitutionMatrix
+	segLen int          // Number of vectors in a column
+	idx    [256]int     // Index in scores, or -1 if the character is missing
+	scores [][]vec      // Substitution scores per character, per segment
+	extA   []vec        // Gap extension scores of the query, per segment
+	extB   [256]float64 // Gap extension scores of the other sequence
+	open   int32        // Gap open score
+}
+
+// NewQueryProfile returns a profile of query a with matrix m. Scores in m
+// should be integers, and gap scores should not be positive.
+func NewQueryProfile(a []byte, m SubstitutionMatrix) *QueryProfile {
+	s := m.toArray()
+	segLen := max((len(a)+lanes-1)/lanes, 1)
+	p := &QueryProfile{a: a, m: m, segLen: segLen}
+	p.open = toInt32(s.get(Gap, Gap))
+	if p.open > 0 {
+		panic(fmt.Sprintf("gap open score should not be positive: %v", p.open))
+	}
+
+	// Position i in a is at segment i%segLen, lane i/segLen.
+	p.extA = make([]vec, segLen)
+	for i := range p.extA {
+		for l := range lanes {
+			if ai := l*segLen + i; ai < len(a) {
+				p.extA[i][l] = toInt32(s.get(a[ai], Gap))
+				if p.extA[i][l] > 0 {
+					panic(fmt.Sprintf(
+						"gap extension score should not be positive: %v",
+						p.extA[i][l]))
+				}
+			}
+		}
+	}
+
+	// Substitution scores of characters that have scores with all of a.
+	for c := range p.idx {
+		p.idx[c] = -1
+		p.extB[c] = math.NaN()
+		if ext := s[Gap][c]; !math.IsNaN(ext) {
+			if ext > 0 {
+				panic(fmt.Sprintf(
+					"gap extension score should not be positive: %v", ext))
+			}
+			p.extB[c] = float64(toInt32(ext))
+		}
+		scores := make([]vec, segLen)
+		ok := true
+		for i := range scores {
+			for l := range lanes {
+				ai := l*segLen + i
+				if ai >= len(a) {
+					scores[i][l] = vecPadding
+					continue
+				}
+				x := s[a[ai]][c]
+				if math.IsNaN(x) {
+					ok = false
+					break
+				}
+				scores[i][l] = toInt32(x)
+			}
+		}
+		if ok {
+			p.idx[c] = len(p.scores)
+			p.scores = append(p.scores, scores)
+		}
+	}
+	return p
+}
+
+// Converts an integer score to int32. Panics if the score is not an integer.
+func toInt32(x float64) int32 {
+	if x != math.Trunc(x) || math.Abs(x) > -vecPadding/2 {
+		panic(fmt.Sprintf("score should be an integer with absolute value "+
+			"of at most %v, got %v", -vecPadding/2, x))
+	}
+	return int32(x)
+}
+
+// LocalScore returns the score of the highest scoring local alignment of the
+// profile's query with b. Returns the same score as Local.
+// Time complexity is O(len(a)*len(b)), and space complexity is O(len(a)).
+func (p *QueryProfile) LocalScore(b []byte) float64 {
+	hPrev, hCur := make([]vec, p.segLen), make([]vec, p.segLen)
+	ePrev, eCur := make([]vec, p.segLen), make([]vec, p.segLen)
+	for i := range ePrev {
+		ePrev[i] = noScores
+	}
+	var best int32
+
+	for _, c := range b {
+		if p.idx[c] == -1 || math.IsNaN(p.extB[c]) {
+			panic(fmt.Sprintf("character %s is missing from the "+
+				"substitution-matrix, with the query or with a gap",
+				charOrGap(c)))
+		}
+		scores := p.scores[p.idx[c]]
+		extB := int32(p.extB[c])
+
+		// The diagonal predecessor of segment 0 is the last segment,
+		// shifted by one lane.
+		vh := shift(hPrev[p.segLen-1], 0)
+		vf := splat(vecNegInf)
+		for i := range p.segLen {
+			var vm, vs vec
+			for l := range lanes {
+				// Match.
+				vm[l] = max(vh[l], 0) + scores[i][l]
+				best = max(best, vm[l])
+				// Insertion (gap in the query).
+				eCur[i][l] = max(hPrev[i][l]+p.open, ePrev[i][l]) + extB
+				// Best of all.
+				vs[l] = max(vm[l], eCur[i][l], vf[l])
+			}
+			hCur[i] = vs
+			vf = p.nextF(vf, vs, i)
+			vh = hPrev[i]
+		}
+
+		// Lazy F loop: propagate deletions that cross segment boundaries.
+		// Deletions that open from the best scores were already counted in
+		// the first pass, so only extensions are propagated here.
+		vf = shift(vf, vecNegInf)
+		for i := 0; p.fMatters(vf, hCur[i]); {
+			for l := range lanes {
+				hCur[i][l] = max(hCur[i][l], vf[l])
+			}
+			vf = p.nextF(vf, noScores, i)
+			i++
+			if i == p.segLen {
+				i = 0
+				vf = shift(vf, vecNegInf)
+			}
+		}
+		hPrev, hCur = hCur, hPrev
+		ePrev, eCur = eCur, ePrev
+	}
+	return float64(best)
+}
+
+// Returns the deletion scores of segment i+1, given those of segment i and the
+// best scores of segment i. Deletion scores of segment segLen are those of
+// segment 0 before shifting, so they should be shifted by the caller.
+func (p *QueryProfile) nextF(vf, vh vec, i int) vec {
+	next := (i + 1) % p.segLen
+	var result vec
+	for l := range lanes {
+		result[l] = max(vh[l]+p.open, vf[l])
+		if next != 0 {
+			result[l] += p.extA[next][l]
+		}
+	}
+	if next == 0 {
+		// Extension scores are added after shifting to the next lane.
+		for l := range lanes {
+			if l < lanes-1 {
+				result[l] += p.extA[0][l+1]
+			}
+		}
+	}
+	return result
+}
+
+// Returns whether the deletion scores vf may change the best scores vh, or the
+// deletion scores that follow.
+func (p *QueryProfile) fMatters(vf, vh vec) bool {
+	for l := range lanes {
+		if vf[l] > vh[l]+p.open {
+			return true
+		}
+	}
+	return false
+}
+
+// Local returns the highest scoring local alignment of the profile's query with
+// b, the same as Local. The alignment is calculated only if its score is at
+// least minScore. Otherwise returns nil steps and start positions -1, along
+// with the score.
+func (p *QueryProfile) Local(b []byte, minScore float64) (
+	steps []Step, ai, bi int, score float64) {
+	score = p.LocalScore(b)
+	if score <= 0 || score < minScore {
+		return nil, -1, -1, score
+	}
+	return Local(p.a, b, p.m)
+}
+
+// Scores that are never opened from.
+var noScores = splat(vecNegInf)
+
+// Returns a vector with all lanes set to x.
+func splat(x int32) vec {
+	var v vec
+	for l := range v {
+		v[l] = x
+	}
+	return v
+}
+
+// Returns v with each lane moved to the next one, and the first lane set to x.
+func shift(v vec, x int32) vec {
+	copy(v[1:], v[:lanes-1])
+	v[0] = x
+	return v
+}
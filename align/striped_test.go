@@ -0,0 +1,92 @@
+package align
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestQueryProfile(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 3,
+		{'b', 'b'}: 2,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -2,
+		{'b', 'c'}: 0,
+		{'a', Gap}: -1,
+		{'b', Gap}: -2,
+		{'c', Gap}: -1,
+		{Gap, Gap}: -2,
+	}.Symmetrical()
+	for range 1000 {
+		a, b := randomSeq(rand.IntN(50), "abc"), randomSeq(rand.IntN(50), "abc")
+		_, _, _, want := Local(a, b, m)
+		if got := NewQueryProfile(a, m).LocalScore(b); got != want {
+			t.Fatalf("LocalScore(%q,%q)=%v, want %v", a, b, got, want)
+		}
+	}
+}
+
+func TestQueryProfile_blosum(t *testing.T) {
+	const chars = "ACDEFGHIKLMNPQRSTVWY"
+	m := BLOSUM62.WithGaps(-11, -1)
+	for range 100 {
+		a, b := randomSeq(rand.IntN(300), chars), randomSeq(rand.IntN(300), chars)
+		// Plant a similar region, to create long alignments.
+		if len(a) > 100 && len(b) > 100 {
+			copy(b[20:], a[30:90])
+			b[40], b[50] = 'W', 'W'
+		}
+		_, _, _, want := Local(a, b, m)
+		if got := NewQueryProfile(a, m).LocalScore(b); got != want {
+			t.Fatalf("LocalScore(%q,%q)=%v, want %v", a, b, got, want)
+		}
+	}
+}
+
+func TestQueryProfile_local(t *testing.T) {
+	a, b := []byte("PEPTIDEWHATEVER"), []byte("WHATEVERPEPTIDE")
+	p := NewQueryProfile(a, BLOSUM62)
+	steps, ai, bi, score := p.Local(b, 100)
+	if steps != nil || ai != -1 || bi != -1 {
+		t.Errorf("Local(%q,100)=%v,%v,%v, want nil,-1,-1", b, steps, ai, bi)
+	}
+	wantSteps, wantAI, wantBI, wantScore := Local(a, b, BLOSUM62)
+	if score != wantScore {
+		t.Errorf("Local(%q,100) score=%v, want %v", b, score, wantScore)
+	}
+	steps, ai, bi, score = p.Local(b, wantScore)
+	if len(steps) != len(wantSteps) || ai != wantAI || bi != wantBI ||
+		score != wantScore {
+		t.Errorf("Local(%q,%v)=%v,%v,%v,%v, want %v,%v,%v,%v", b, wantScore,
+			steps, ai, bi, score, wantSteps, wantAI, wantBI, wantScore)
+	}
+}
+
+func TestNewQueryProfile_bad(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewQueryProfile with a fractional score did not panic")
+		}
+	}()
+	NewQueryProfile([]byte("a"), SubstitutionMatrix{
+		{'a', 'a'}: 1.5, {'a', Gap}: -1, {Gap, 'a'}: -1, {Gap, Gap}: 0,
+	})
+}
+
+func BenchmarkLocal(b *testing.B) {
+	x, y := randomSeq(1000, "ACDEFGHIKLMNPQRSTVWY"),
+		randomSeq(1000, "ACDEFGHIKLMNPQRSTVWY")
+	for b.Loop() {
+		Local(x, y, BLOSUM62)
+	}
+}
+
+func BenchmarkQueryProfile(b *testing.B) {
+	x, y := randomSeq(1000, "ACDEFGHIKLMNPQRSTVWY"),
+		randomSeq(1000, "ACDEFGHIKLMNPQRSTVWY")
+	p := NewQueryProfile(x, BLOSUM62)
+	for b.Loop() {
+		p.LocalScore(y)
+	}
+}
@@ -0,0 +1,52 @@
+package align
+
+import "iter"
+
+// LocalAlignment is a single local alignment of two sequences.
+type LocalAlignment struct {
+	Steps  []Step  // Steps relating to a
+	AI, BI int     // Start positions in a and b
+	Score  float64 // Alignment score
+}
+
+// LocalAll iterates over non-overlapping local alignments of a and b, with
+// scores of at least minScore, from the highest scoring to the lowest. Two
+// alignments overlap if they match the same pair of characters. The first
+// alignment is the same as the one returned by Local.
+// Time complexity is O(len(a)*len(b)) per alignment, and space complexity is
+// O(len(a)*len(b)).
+//
+// Uses the Waterman-Eggert algorithm.
+func LocalAll(a, b []byte, m SubstitutionMatrix,
+	minScore float64) iter.Seq[LocalAlignment] {
+	return func(yield func(LocalAlignment) bool) {
+		pos := seqPositions(a, m.toArray())
+		bn := len(b) + 1
+		mask := make([]bool, (len(a)+1)*bn)
+		for {
+			trace, end, last, score := gotoh(pos, b, 0, true, mask)
+			if score <= 0 || score < minScore {
+				return
+			}
+			steps, start := traceSteps(trace, bn, end, last)
+
+			// Mask matched pairs.
+			i := start
+			for _, step := range steps {
+				switch step {
+				case Match:
+					i += bn + 1
+					mask[i] = true
+				case Deletion:
+					i += bn
+				case Insertion:
+					i += 1
+				}
+			}
+
+			if !yield(LocalAlignment{steps, start / bn, start % bn, score}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package align
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocalAll(t *testing.T) {
+	m := SubstitutionMatrix{
+		{'a', 'a'}: 1,
+		{'b', 'b'}: 1,
+		{'c', 'c'}: 1,
+		{'a', 'b'}: -1,
+		{'a', 'c'}: -1,
+		{'b', 'c'}: -1,
+	}.Symmetrical().WithGaps(-2, -1)
+	a := []byte("abccabcbbcaaabc")
+	b := []byte("abc")
+	want := []LocalAlignment{
+		{[]Step{Match, Match, Match}, 0, 0, 3},
+		{[]Step{Match, Match, Match}, 4, 0, 3},
+		{[]Step{Match, Match, Match}, 12, 0, 3},
+		{[]Step{Match, Match}, 8, 1, 2},
+	}
+	var got []LocalAlignment
+	for aln := range LocalAll(a, b, m, 2) {
+		got = append(got, aln)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("LocalAll(%q,%q,2)=%v, want %v", a, b, got, want)
+	}
+
+	got = nil
+	for aln := range LocalAll(a, b, m, 3) {
+		got = append(got, aln)
+	}
+	if !reflect.DeepEqual(got, want[:3]) {
+		t.Fatalf("LocalAll(%q,%q,3)=%v, want %v", a, b, got, want[:3])
+	}
+}
+
+func TestLocalAll_first(t *testing.T) {
+	a, b := []byte("HEAGAWGHEE"), []byte("PAWHEAE")
+	steps, ai, bi, score := Local(a, b, BLOSUM62)
+	want := LocalAlignment{steps, ai, bi, score}
+	for got := range LocalAll(a, b, BLOSUM62, 0) {
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("LocalAll(%q,%q)=%v, want %v", a, b, got, want)
+		}
+		break
+	}
+}
@@ -0,0 +1,140 @@
+// Package bgzf encodes and decodes the blocked gzip format (BGZF).
+//
+// BGZF files are concatenations of gzip members (blocks), each holding up to
+// 64KB of data, with the compressed size of the block in the gzip header.
+// This allows random access to compressed data, as in BAM files and indexed
+// fasta files. BGZF files can be read by any gzip reader.
+//
+// This package uses the format described in the SAM specification:
+// https://samtools.github.io/hts-specs/SAMv1.pdf
+package bgzf
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+const (
+	// Maximal number of uncompressed bytes in a block, as in htslib.
+	maxDataSize = 0xff00
+
+	// Number of bytes in a block header.
+	headerSize = 18
+)
+
+// An empty block that marks the end of a BGZF file.
+var eofBlock = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x06, 0x00,
+	0x42, 0x43, 0x02, 0x00, 0x1b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00,
+}
+
+// A Writer compresses data in BGZF format.
+type Writer struct {
+	w      io.Writer
+	data   []byte        // Data of the current block
+	block  *bytes.Buffer // Compressed block
+	fw     *flate.Writer // Compresses into block
+	closer io.Closer     // Underlying file, for writers from Create
+	err    error         // First error that occurred
+}
+
+// NewWriter returns a writer that compresses data into w. Close should be
+// called at the end to write the remaining data and the end-of-file marker.
+func NewWriter(w io.Writer) *Writer {
+	block := &bytes.Buffer{}
+	fw, _ := flate.NewWriter(block, flate.DefaultCompression)
+	return &Writer{
+		w:     w,
+		data:  make([]byte, 0, maxDataSize),
+		block: block,
+		fw:    fw,
+	}
+}
+
+// Create opens a file for writing in BGZF format. Close closes the file as
+// well.
+func Create(file string) (*Writer, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	w := NewWriter(f)
+	w.closer = f
+	return w, nil
+}
+
+// Write compresses p into the writer. Data is written to the underlying writer
+// in whole blocks.
+func (w *Writer) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if w.err != nil {
+			return n, w.err
+		}
+		m := min(len(p), maxDataSize-len(w.data))
+		w.data = append(w.data, p[:m]...)
+		p = p[m:]
+		n += m
+		if len(w.data) == maxDataSize {
+			w.err = w.writeBlock()
+		}
+	}
+	return n, w.err
+}
+
+// Flush writes the buffered data as a block, so that the data written so far
+// can be decompressed.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.data) > 0 {
+		w.err = w.writeBlock()
+	}
+	return w.err
+}
+
+// Close writes the buffered data and the end-of-file marker. Does not close
+// the underlying writer, unless the writer was created with Create.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(eofBlock); err != nil {
+		w.err = err
+		return err
+	}
+	w.err = os.ErrClosed
+	if w.closer != nil {
+		return w.closer.Close()
+	}
+	return nil
+}
+
+// Compresses and writes the buffered data as a single block.
+func (w *Writer) writeBlock() error {
+	w.block.Reset()
+	w.block.Write(make([]byte, headerSize))
+	w.fw.Reset(w.block)
+	if _, err := w.fw.Write(w.data); err != nil {
+		return err
+	}
+	if err := w.fw.Close(); err != nil {
+		return err
+	}
+	w.block.Write(binary.LittleEndian.AppendUint32(nil,
+		crc32.ChecksumIEEE(w.data)))
+	w.block.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(w.data))))
+
+	b := w.block.Bytes()
+	copy(b, eofBlock[:16]) // Same header, up to the block size.
+	binary.LittleEndian.PutUint16(b[16:], uint16(len(b)-1))
+	w.data = w.data[:0]
+	_, err := w.w.Write(b)
+	return err
+}
@@ -0,0 +1,89 @@
+package bgzf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	for _, n := range []int{0, 1, 100, maxDataSize, maxDataSize + 1, 300000} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = "ACGT"[rand.IntN(4)]
+		}
+		buf := &bytes.Buffer{}
+		w := NewWriter(buf)
+		// Write in pieces of random sizes.
+		for p := data; len(p) > 0; {
+			m := min(len(p), rand.IntN(100000)+1)
+			if _, err := w.Write(p[:m]); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			p = p[m:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		// Check block sizes.
+		b := buf.Bytes()
+		if !bytes.HasSuffix(b, eofBlock) {
+			t.Fatalf("output does not end with an EOF block")
+		}
+		blocks := 0
+		for len(b) > 0 {
+			if len(b) < headerSize || b[12] != 'B' || b[13] != 'C' {
+				t.Fatalf("bad block header: %v", b[:min(len(b), headerSize)])
+			}
+			size := int(binary.LittleEndian.Uint16(b[16:])) + 1
+			b = b[size:]
+			blocks++
+		}
+		if want := (n+maxDataSize-1)/maxDataSize + 1; blocks != want {
+			t.Fatalf("got %v blocks for %v bytes, want %v", blocks, n, want)
+		}
+
+		// Decompress with gzip.
+		r, err := gzip.NewReader(buf)
+		if err != nil {
+			t.Fatalf("gzip.NewReader failed: %v", err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("ReadAll failed: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("got %v bytes, want %v", len(got), len(data))
+		}
+	}
+}
+
+func TestWriter_flush(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	w.Write([]byte("hello"))
+	if buf.Len() != 0 {
+		t.Fatalf("Write wrote %v bytes before Flush, want 0", buf.Len())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip.NewReader failed: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil || string(got) != "hello" {
+		t.Fatalf("ReadAll()=%q,%v, want \"hello\"", got, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Fatalf("Write after Close succeeded, want error")
+	}
+}
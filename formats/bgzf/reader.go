@@ -0,0 +1,129 @@
+// BGZF decoding.
+
+package bgzf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// A Reader decompresses data in BGZF format.
+type Reader struct {
+	r      *bufio.Reader
+	buf    []byte        // Compressed block
+	data   []byte        // Data of the current block
+	fr     io.ReadCloser // Decompresses blocks
+	closer io.Closer     // Underlying file, for readers from Open
+	err    error         // First error that occurred
+}
+
+// NewReader returns a reader that decompresses data from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), fr: flate.NewReader(nil)}
+}
+
+// Open opens a BGZF file for reading. Close closes the file.
+func Open(file string) (*Reader, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	r := NewReader(f)
+	r.closer = f
+	return r, nil
+}
+
+// Read decompresses data from the underlying reader into p.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.readBlock()
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// Close closes the underlying file, if the reader was created with Open.
+func (r *Reader) Close() error {
+	r.err = os.ErrClosed
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// Reads and decompresses the next block into r.data. Returns io.EOF if there
+// are no more blocks.
+func (r *Reader) readBlock() error {
+	// Fixed part of the gzip header, and the extra field length.
+	var header [12]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("bgzf: truncated block header")
+		}
+		return err // io.EOF at the end of the data.
+	}
+	if !bytes.Equal(header[:4], eofBlock[:4]) {
+		return fmt.Errorf("bgzf: bad block header: %x", header[:4])
+	}
+	extra := make([]byte, binary.LittleEndian.Uint16(header[10:]))
+	if _, err := io.ReadFull(r.r, extra); err != nil {
+		return noEOF(err)
+	}
+
+	// Find the block size in the extra subfields.
+	bsize := -1
+	for len(extra) >= 4 {
+		slen := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+slen {
+			break
+		}
+		if extra[0] == 'B' && extra[1] == 'C' && slen == 2 {
+			bsize = int(binary.LittleEndian.Uint16(extra[4:])) + 1
+		}
+		extra = extra[4+slen:]
+	}
+	rest := bsize - len(header) - int(binary.LittleEndian.Uint16(header[10:]))
+	if bsize == -1 || rest < 8 {
+		return fmt.Errorf("bgzf: block has no valid BC field")
+	}
+
+	// Compressed data and footer.
+	r.buf = append(r.buf[:0], make([]byte, rest)...)
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		return noEOF(err)
+	}
+	footer := r.buf[rest-8:]
+	crc := binary.LittleEndian.Uint32(footer)
+	size := binary.LittleEndian.Uint32(footer[4:])
+	if size > 1<<16 {
+		return fmt.Errorf("bgzf: bad block data size: %v", size)
+	}
+	r.fr.(flate.Resetter).Reset(bytes.NewReader(r.buf[:rest-8]), nil)
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.fr, data); err != nil {
+		return fmt.Errorf("bgzf: %w", noEOF(err))
+	}
+	if crc32.ChecksumIEEE(data) != crc {
+		return fmt.Errorf("bgzf: checksum mismatch")
+	}
+	r.data = data
+	return nil
+}
+
+// Converts io.EOF to io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package bgzf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	for _, n := range []int{0, 1, 100, maxDataSize, maxDataSize + 1, 300000} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = "ACGT"[rand.IntN(4)]
+		}
+		buf := &bytes.Buffer{}
+		w := NewWriter(buf)
+		// Write in pieces of random sizes, with some small blocks.
+		for p := data; len(p) > 0; {
+			m := min(len(p), rand.IntN(100000)+1)
+			if _, err := w.Write(p[:m]); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			if rand.IntN(2) == 0 {
+				w.Flush()
+			}
+			p = p[m:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		got, err := io.ReadAll(NewReader(buf))
+		if err != nil {
+			t.Fatalf("ReadAll failed: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("got %v bytes, want %v", len(got), len(data))
+		}
+	}
+}
+
+func TestReader_bad(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	w.Write([]byte("hello world"))
+	w.Close()
+	good := buf.Bytes()
+
+	gz := &bytes.Buffer{}
+	gw := gzip.NewWriter(gz)
+	gw.Write([]byte("hello world"))
+	gw.Close()
+
+	corrupt := bytes.Clone(good)
+	corrupt[len(corrupt)-len(eofBlock)-8]++ // CRC
+
+	inputs := map[string][]byte{
+		"truncated": good[:len(good)-len(eofBlock)-3],
+		"header":    good[:5],
+		"gzip":      gz.Bytes(),
+		"crc":       corrupt,
+		"text":      []byte("hello world"),
+	}
+	for name, input := range inputs {
+		if _, err := io.ReadAll(NewReader(bytes.NewReader(input))); err == nil {
+			t.Errorf("ReadAll(%s) succeeded, want error", name)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.gz")
+	w, err := Create(file)
+	if err != nil {
+		t.Fatalf("Create(%q) failed: %v", file, err)
+	}
+	w.Write([]byte("hello world"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	r, err := Open(file)
+	if err != nil {
+		t.Fatalf("Open(%q) failed: %v", file, err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(got) != "hello world" {
+		t.Fatalf("ReadAll()=%q, want %q", got, "hello world")
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+}
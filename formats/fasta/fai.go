@@ -0,0 +1,317 @@
+// FASTA index (.fai) handling.
+
+package fasta
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// An IndexEntry describes the location of a single sequence in a fasta file.
+type IndexEntry struct {
+	Name      string // Sequence name, up to the first whitespace
+	Length    int    // Number of bases
+	Offset    int64  // Byte offset of the first base
+	LineBases int    // Number of bases in each line
+	LineWidth int    // Number of bytes in each line, including the line break
+}
+
+// An Index is a fasta index, as in the .fai files of samtools faidx. It allows
+// reading parts of a fasta file without reading the whole file.
+//
+// Indexed files should have the same line length throughout each sequence,
+// except for the last line.
+type Index []IndexEntry
+
+// MakeIndex returns the index of the fasta data in r.
+func MakeIndex(r io.Reader) (Index, error) {
+	br := bufio.NewReader(r)
+	var idx Index
+	var cur *IndexEntry
+	var pos int64
+	short := false // Whether a line shorter than the first was seen.
+	for lineNum := 1; ; lineNum++ {
+		line, n, breaks, err := readIndexLine(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		pos += int64(n)
+
+		if line != nil {
+			name := ""
+			if fields := bytes.Fields(line[1:]); len(fields) > 0 {
+				name = string(fields[0])
+			}
+			idx = append(idx, IndexEntry{Name: name, Offset: pos})
+			cur = &idx[len(idx)-1]
+			short = false
+			continue
+		}
+		bases := n - breaks
+		if bases == 0 {
+			short = true
+			continue
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("line %v: sequence before the first name",
+				lineNum)
+		}
+		if short {
+			return nil, fmt.Errorf("line %v: different line length in "+
+				"sequence %q", lineNum, cur.Name)
+		}
+		if cur.LineBases == 0 {
+			cur.LineBases, cur.LineWidth = bases, n
+		} else if bases > cur.LineBases ||
+			breaks != cur.LineWidth-cur.LineBases && breaks != 0 {
+			return nil, fmt.Errorf("line %v: different line length in "+
+				"sequence %q", lineNum, cur.Name)
+		} else if bases < cur.LineBases {
+			short = true
+		}
+		cur.Length += bases
+	}
+	return idx, nil
+}
+
+// Reads a single line from br. Returns the line if it is a name line, or nil
+// otherwise. Also returns the number of bytes in the line, and how many of
+// them are the line break.
+func readIndexLine(br *bufio.Reader) (name []byte, n, breaks int, err error) {
+	isName, prevCR := false, false
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if n == 0 && len(chunk) > 0 && chunk[0] == '>' {
+			isName = true
+		}
+		if isName {
+			name = append(name, chunk...)
+		}
+		n += len(chunk)
+		if err == bufio.ErrBufferFull {
+			prevCR = chunk[len(chunk)-1] == '\r'
+			continue
+		}
+		if err == io.EOF && n > 0 {
+			err = nil
+		}
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		breaks = len(chunk) - len(bytes.TrimRight(chunk, "\r\n"))
+		if breaks == len(chunk) && prevCR {
+			breaks++ // A \r\n that was split between chunks.
+		}
+		return name, n, breaks, nil
+	}
+}
+
+// ReadIndex decodes a fasta index in .fai format from r.
+func ReadIndex(r io.Reader) (Index, error) {
+	var idx Index
+	sc := bufio.NewScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		if sc.Text() == "" {
+			continue
+		}
+		fields := strings.Split(sc.Text(), "\t")
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("line %v: bad number of fields: %v, "+
+				"want at least 5", lineNum, len(fields))
+		}
+		var nums [4]int64
+		for i := range nums {
+			x, err := strconv.ParseInt(fields[i+1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %v", lineNum, err)
+			}
+			if x < 0 {
+				return nil, fmt.Errorf("line %v: negative value: %v",
+					lineNum, x)
+			}
+			nums[i] = x
+		}
+		e := IndexEntry{fields[0], int(nums[0]), nums[1], int(nums[2]),
+			int(nums[3])}
+		if e.LineBases > e.LineWidth || e.LineBases == 0 && e.Length > 0 {
+			return nil, fmt.Errorf("line %v: bad line lengths: %v, %v",
+				lineNum, e.LineBases, e.LineWidth)
+		}
+		idx = append(idx, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Write writes the index in .fai format to w.
+func (idx Index) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range idx {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n",
+			e.Name, e.Length, e.Offset, e.LineBases, e.LineWidth)
+	}
+	return bw.Flush()
+}
+
+// A Region is a range of bases in a named sequence.
+type Region struct {
+	Name  string // Sequence name
+	Start int    // Start position, 0-based inclusive
+	End   int    // End position, 0-based exclusive, or -1 for the end
+}
+
+// ParseRegion parses a region in samtools format, such as
+// "chr7:117,480,000-117,670,000". Positions are 1-based and inclusive, and
+// are converted to a 0-based half-open Region. "chr7:1000" means position 1000
+// to the end, and "chr7" means the whole sequence. Names that contain ':' are
+// supported, as long as the part after the last ':' is not a valid range.
+func ParseRegion(s string) (Region, error) {
+	if s == "" {
+		return Region{}, fmt.Errorf("empty region")
+	}
+	i := strings.LastIndexByte(s, ':')
+	if i == -1 {
+		return Region{s, 0, -1}, nil
+	}
+	rng := strings.ReplaceAll(s[i+1:], ",", "")
+	from, to, hasTo := strings.Cut(rng, "-")
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 1 {
+		return Region{s, 0, -1}, nil // A name with a colon.
+	}
+	if !hasTo {
+		return Region{s[:i], start - 1, -1}, nil
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil {
+		return Region{s, 0, -1}, nil // A name with a colon.
+	}
+	if end < start {
+		return Region{}, fmt.Errorf("bad region %q: end is before start", s)
+	}
+	return Region{s[:i], start - 1, end}, nil
+}
+
+// String returns the region in samtools format, with 1-based inclusive
+// positions.
+func (r Region) String() string {
+	if r.End == -1 {
+		if r.Start == 0 {
+			return r.Name
+		}
+		return fmt.Sprintf("%s:%d", r.Name, r.Start+1)
+	}
+	return fmt.Sprintf("%s:%d-%d", r.Name, r.Start+1, r.End)
+}
+
+// An IndexedReader reads regions from an indexed fasta file.
+type IndexedReader struct {
+	r      io.ReaderAt
+	idx    Index
+	byName map[string]int
+	closer io.Closer
+}
+
+// NewIndexedReader returns a reader of regions from r, using the given index.
+func NewIndexedReader(r io.ReaderAt, idx Index) *IndexedReader {
+	byName := make(map[string]int, len(idx))
+	for i, e := range idx {
+		if _, ok := byName[e.Name]; !ok {
+			byName[e.Name] = i
+		}
+	}
+	return &IndexedReader{r: r, idx: idx, byName: byName}
+}
+
+// OpenIndexed opens an uncompressed fasta file for reading regions, using the
+// index in file+".fai".
+func OpenIndexed(file string) (*IndexedReader, error) {
+	fi, err := os.Open(file + ".fai")
+	if err != nil {
+		return nil, err
+	}
+	idx, err := ReadIndex(fi)
+	fi.Close()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	r := NewIndexedReader(f, idx)
+	r.closer = f
+	return r, nil
+}
+
+// Close closes the underlying file, if the reader was created with
+// OpenIndexed.
+func (r *IndexedReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// Index returns the index that the reader uses.
+func (r *IndexedReader) Index() Index {
+	return r.idx
+}
+
+// Read returns the bases in the given region. An end past the end of the
+// sequence is treated as the end of the sequence.
+func (r *IndexedReader) Read(reg Region) ([]byte, error) {
+	i, ok := r.byName[reg.Name]
+	if !ok {
+		return nil, fmt.Errorf("sequence %q is not in the index", reg.Name)
+	}
+	e := r.idx[i]
+	end := reg.End
+	if end == -1 || end > e.Length {
+		end = e.Length
+	}
+	if reg.Start < 0 || reg.Start > end {
+		return nil, fmt.Errorf("bad region %v for sequence of length %v",
+			reg, e.Length)
+	}
+	if reg.Start == end {
+		return []byte{}, nil
+	}
+
+	// Offset of a base in the file.
+	offset := func(i int) int64 {
+		return e.Offset + int64(i/e.LineBases)*int64(e.LineWidth) +
+			int64(i%e.LineBases)
+	}
+	from, to := offset(reg.Start), offset(end-1)+1
+	buf := make([]byte, to-from)
+	if n, err := r.r.ReadAt(buf, from); n < len(buf) {
+		if err == io.EOF || err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	// Remove line breaks.
+	result := buf[:0]
+	for _, b := range buf {
+		if b != '\n' && b != '\r' {
+			result = append(result, b)
+		}
+	}
+	if len(result) != end-reg.Start {
+		return nil, fmt.Errorf("read %v bases for region %v, want %v",
+			len(result), reg, end-reg.Start)
+	}
+	return result, nil
+}
@@ -0,0 +1,253 @@
+package fasta
+
+import (
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMakeIndex(t *testing.T) {
+	input := ">one desc\nACGTA\nCGTAC\nGT\n>two\r\nAAA\r\nCC\r\n\n>three\n>four\nACG"
+	want := Index{
+		{"one", 12, 10, 5, 6},
+		{"two", 5, 31, 3, 5},
+		{"three", 0, 48, 0, 0},
+		{"four", 3, 54, 3, 3},
+	}
+	got, err := MakeIndex(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("MakeIndex(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MakeIndex(%q)=%v, want %v", input, got, want)
+	}
+}
+
+func TestMakeIndex_bad(t *testing.T) {
+	tests := []string{
+		"ACGT\n",
+		">a\nACG\nACGT\n",
+		">a\nACGT\nAC\nAC\n",
+		">a\nACGT\n\nACGT\n",
+		">a\nACGT\r\nACGT\n",
+	}
+	for _, test := range tests {
+		if got, err := MakeIndex(strings.NewReader(test)); err == nil {
+			t.Errorf("MakeIndex(%q)=%v, want error", test, got)
+		}
+	}
+}
+
+func TestIndex_writeRead(t *testing.T) {
+	idx := Index{
+		{"one", 12, 10, 5, 6},
+		{"two", 5, 37, 3, 5},
+	}
+	buf := &strings.Builder{}
+	if err := idx.Write(buf); err != nil {
+		t.Fatalf("Write(%v) failed: %v", idx, err)
+	}
+	want := "one\t12\t10\t5\t6\ntwo\t5\t37\t3\t5\n"
+	if buf.String() != want {
+		t.Fatalf("Write(%v)=%q, want %q", idx, buf.String(), want)
+	}
+	got, err := ReadIndex(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ReadIndex(%q) failed: %v", buf.String(), err)
+	}
+	if !reflect.DeepEqual(got, idx) {
+		t.Fatalf("ReadIndex(%q)=%v, want %v", buf.String(), got, idx)
+	}
+}
+
+func TestReadIndex_bad(t *testing.T) {
+	tests := []string{
+		"one\t12\t10\t5\n",
+		"one\t12\t10\t5\tx\n",
+		"one\t12\t-10\t5\t6\n",
+		"one\t12\t10\t6\t5\n",
+	}
+	for _, test := range tests {
+		if got, err := ReadIndex(strings.NewReader(test)); err == nil {
+			t.Errorf("ReadIndex(%q)=%v, want error", test, got)
+		}
+	}
+}
+
+func TestIndexedReader(t *testing.T) {
+	for range 20 {
+		// Random sequences with random line widths.
+		var fas []*Fasta
+		text := &strings.Builder{}
+		for i := range rand.IntN(5) + 1 {
+			fa := &Fasta{Name: []byte{'a' + byte(i)}}
+			for range rand.IntN(100) {
+				fa.Sequence = append(fa.Sequence, "ACGT"[rand.IntN(4)])
+			}
+			fas = append(fas, fa)
+			lineLen := rand.IntN(10) + 1
+			text.WriteString(">" + string(fa.Name) + "\n")
+			for j := 0; j < len(fa.Sequence); j += lineLen {
+				text.Write(fa.Sequence[j:min(j+lineLen, len(fa.Sequence))])
+				text.WriteString("\n")
+			}
+		}
+
+		idx, err := MakeIndex(strings.NewReader(text.String()))
+		if err != nil {
+			t.Fatalf("MakeIndex(%q) failed: %v", text, err)
+		}
+		r := NewIndexedReader(strings.NewReader(text.String()), idx)
+		for _, fa := range fas {
+			start := rand.IntN(len(fa.Sequence) + 1)
+			end := start + rand.IntN(len(fa.Sequence)-start+1)
+			reg := Region{string(fa.Name), start, end}
+			got, err := r.Read(reg)
+			if err != nil {
+				t.Fatalf("Read(%v) failed: %v", reg, err)
+			}
+			if want := fa.Sequence[start:end]; string(got) != string(want) {
+				t.Fatalf("Read(%v)=%q, want %q", reg, got, want)
+			}
+			reg = Region{string(fa.Name), 0, -1}
+			got, err = r.Read(reg)
+			if err != nil {
+				t.Fatalf("Read(%v) failed: %v", reg, err)
+			}
+			if string(got) != string(fa.Sequence) {
+				t.Fatalf("Read(%v)=%q, want %q", reg, got, fa.Sequence)
+			}
+		}
+	}
+}
+
+// A ReaderAt that returns io.EOF along with reads that reach the end.
+type eofReaderAt struct {
+	*strings.Reader
+}
+
+func (r eofReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.Reader.ReadAt(p, off)
+	if err == nil && off+int64(n) == r.Size() {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func TestIndexedReader_eof(t *testing.T) {
+	text := ">a\nACGT\nAC"
+	idx, err := MakeIndex(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("MakeIndex(%q) failed: %v", text, err)
+	}
+	r := NewIndexedReader(eofReaderAt{strings.NewReader(text)}, idx)
+	reg := Region{"a", 2, -1}
+	got, err := r.Read(reg)
+	if err != nil {
+		t.Fatalf("Read(%v) failed: %v", reg, err)
+	}
+	if string(got) != "GTAC" {
+		t.Fatalf("Read(%v)=%q, want %q", reg, got, "GTAC")
+	}
+}
+
+func TestIndexedReader_bad(t *testing.T) {
+	text := ">a\nACGT\nAC\n"
+	idx, err := MakeIndex(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("MakeIndex(%q) failed: %v", text, err)
+	}
+	r := NewIndexedReader(strings.NewReader(text), idx)
+	for _, reg := range []Region{{"b", 0, 1}, {"a", 7, 8}, {"a", 3, 2}} {
+		if got, err := r.Read(reg); err == nil {
+			t.Errorf("Read(%v)=%q, want error", reg, got)
+		}
+	}
+}
+
+func TestOpenIndexed(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "x.fa")
+	text := ">chr1\nAAAAACCCCC\nGGGGGTTTTT\nAC\n>chr2\nTTTT\n"
+	if err := os.WriteFile(file, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := MakeIndex(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("MakeIndex(%q) failed: %v", text, err)
+	}
+	f, err := os.Create(file + ".fai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	r, err := OpenIndexed(file)
+	if err != nil {
+		t.Fatalf("OpenIndexed(%q) failed: %v", file, err)
+	}
+	defer r.Close()
+	reg, err := ParseRegion("chr1:9-12")
+	if err != nil {
+		t.Fatalf("ParseRegion failed: %v", err)
+	}
+	got, err := r.Read(reg)
+	if err != nil {
+		t.Fatalf("Read(%v) failed: %v", reg, err)
+	}
+	if want := "CCGG"; string(got) != want {
+		t.Fatalf("Read(%v)=%q, want %q", reg, got, want)
+	}
+}
+
+func TestParseRegion(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Region
+	}{
+		{"chr7", Region{"chr7", 0, -1}},
+		{"chr7:1000", Region{"chr7", 999, -1}},
+		{"chr7:117,480,000-117,670,000", Region{"chr7", 117479999, 117670000}},
+		{"chr7:5-5", Region{"chr7", 4, 5}},
+		{"HLA-A*01:01", Region{"HLA-A*01", 0, -1}},
+		{"HLA-A*01:01:1-10", Region{"HLA-A*01:01", 0, 10}},
+		{"a:b", Region{"a:b", 0, -1}},
+	}
+	for _, test := range tests {
+		got, err := ParseRegion(test.input)
+		if err != nil {
+			t.Fatalf("ParseRegion(%q) failed: %v", test.input, err)
+		}
+		if got != test.want {
+			t.Errorf("ParseRegion(%q)=%v, want %v", test.input, got, test.want)
+		}
+	}
+	for _, test := range []string{"", "chr7:10-5"} {
+		if got, err := ParseRegion(test); err == nil {
+			t.Errorf("ParseRegion(%q)=%v, want error", test, got)
+		}
+	}
+}
+
+func TestRegion_String(t *testing.T) {
+	tests := []struct {
+		input Region
+		want  string
+	}{
+		{Region{"chr7", 0, -1}, "chr7"},
+		{Region{"chr7", 999, -1}, "chr7:1000"},
+		{Region{"chr7", 4, 5}, "chr7:5-5"},
+	}
+	for _, test := range tests {
+		if got := test.input.String(); got != test.want {
+			t.Errorf("%#v.String()=%q, want %q", test.input, got, test.want)
+		}
+	}
+}
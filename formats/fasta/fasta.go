@@ -4,6 +4,9 @@
 // https://en.wikipedia.org/wiki/FASTA_format
 //
 // This package does not validate sequence characters.
+//
+// Parts of large files can be read without reading the whole file, using a
+// samtools-compatible index (.fai) and IndexedReader.
 package fasta
 
 import (
@@ -43,15 +46,97 @@ func (f *Fasta) MarshalText() ([]byte, error) {
 // Write writes this entry in textual Fasta format to the given writer.
 // Includes a trailing new line.
 func (f *Fasta) Write(w io.Writer) error {
-	if _, err := fmt.Fprintf(w, ">%s\n", f.Name); err != nil {
+	return f.write(w, textLineLen)
+}
+
+// Writes this entry to w, with sequence lines of the given width. A width of 0
+// means a single line.
+func (f *Fasta) write(w io.Writer, width int) error {
+	if width == 0 {
+		width = max(len(f.Sequence), 1)
+	}
+	if err := writeLine(w, nameStart, f.Name); err != nil {
 		return err
 	}
-	for i := 0; i < len(f.Sequence); i += textLineLen {
-		to := min(i+textLineLen, len(f.Sequence))
-		if _, err := fmt.Fprintf(w, "%s\n", f.Sequence[i:to]); err != nil {
+	for i := 0; i < len(f.Sequence); i += width {
+		to := min(i+width, len(f.Sequence))
+		if err := writeLine(w, f.Sequence[i:to]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writes the given parts to w, followed by a new line.
+func writeLine(w io.Writer, parts ...[]byte) error {
+	for _, part := range parts {
+		if _, err := w.Write(part); err != nil {
 			return err
 		}
 	}
+	_, err := w.Write(newLine)
+	return err
+}
+
+// Byte slices for writing.
+var (
+	nameStart = []byte{'>'}
+	newLine   = []byte{'\n'}
+)
+
+// A Writer writes fasta entries with buffering, for writing many entries
+// efficiently.
+type Writer struct {
+	w      *bufio.Writer
+	width  int
+	closer io.Closer
+}
+
+// NewWriter returns a writer that writes to w, with sequence lines of the given
+// width. NCBI uses a width of 60 and samtools uses 80. A width of 0 writes each
+// sequence in a single line. Flush should be called at the end.
+//
+// To write BGZF-compressed output, use a writer from bgzf.NewWriter.
+func NewWriter(w io.Writer, width int) *Writer {
+	if width < 0 {
+		panic(fmt.Sprintf("bad width: %v", width))
+	}
+	return &Writer{w: bufio.NewWriter(w), width: width}
+}
+
+// Create opens a file for writing fasta entries, as in NewWriter. Output is
+// compressed according to the file's suffix (.gz, .zst), as in aio.Create.
+// Close should be called at the end.
+func Create(file string, width int) (*Writer, error) {
+	if width < 0 {
+		panic(fmt.Sprintf("bad width: %v", width))
+	}
+	f, err := aio.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{w: &f.Writer, width: width, closer: f}, nil
+}
+
+// Write writes a single entry.
+func (w *Writer) Write(f *Fasta) error {
+	return f.write(w.w, w.width)
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Close flushes the buffered data. If the writer was created with Create,
+// also closes the file.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if w.closer != nil {
+		return w.closer.Close()
+	}
 	return nil
 }
 
@@ -2,10 +2,15 @@ package fasta
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/fluhus/biostuff/formats/bgzf"
 )
 
 func TestReader_simple(t *testing.T) {
@@ -103,3 +108,106 @@ func BenchmarkMarshalText(b *testing.B) {
 		fa.MarshalText()
 	}
 }
+
+func TestWriter(t *testing.T) {
+	fas := []*Fasta{
+		{[]byte("a"), []byte("ACGTACGTAC")},
+		{[]byte("b"), nil},
+		{[]byte("c"), []byte("ACGT")},
+	}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{0, ">a\nACGTACGTAC\n>b\n>c\nACGT\n"},
+		{4, ">a\nACGT\nACGT\nAC\n>b\n>c\nACGT\n"},
+		{60, ">a\nACGTACGTAC\n>b\n>c\nACGT\n"},
+	}
+	for _, test := range tests {
+		buf := &strings.Builder{}
+		w := NewWriter(buf, test.width)
+		for _, fa := range fas {
+			if err := w.Write(fa); err != nil {
+				t.Fatalf("Write(%v) failed: %v", fa, err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("Flush() failed: %v", err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("NewWriter(%v) wrote %q, want %q",
+				test.width, buf.String(), test.want)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	fas := []*Fasta{
+		{[]byte("a"), []byte("ACGTACGTAC")},
+		{[]byte("b"), []byte("ACGT")},
+	}
+	for _, name := range []string{"x.fa", "x.fa.gz"} {
+		file := filepath.Join(t.TempDir(), name)
+		w, err := Create(file, 3)
+		if err != nil {
+			t.Fatalf("Create(%q) failed: %v", file, err)
+		}
+		for _, fa := range fas {
+			if err := w.Write(fa); err != nil {
+				t.Fatalf("Write(%v) failed: %v", fa, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() failed: %v", err)
+		}
+		var got []*Fasta
+		for fa, err := range File(file) {
+			if err != nil {
+				t.Fatalf("File(%q) failed: %v", file, err)
+			}
+			got = append(got, fa)
+		}
+		if !reflect.DeepEqual(got, fas) {
+			t.Errorf("File(%q)=%v, want %v", file, got, fas)
+		}
+	}
+}
+
+func TestWriter_bgzf(t *testing.T) {
+	fa := &Fasta{[]byte("a"), bytes.Repeat([]byte("ACGT"), 100)}
+	buf := &bytes.Buffer{}
+	bw := bgzf.NewWriter(buf)
+	w := NewWriter(bw, 60)
+	if err := w.Write(fa); err != nil {
+		t.Fatalf("Write(%v) failed: %v", fa, err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() failed: %v", err)
+	}
+	if err := bw.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	zr, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() failed: %v", err)
+	}
+	var got []*Fasta
+	for fa, err := range Reader(zr) {
+		if err != nil {
+			t.Fatalf("Reader() failed: %v", err)
+		}
+		got = append(got, fa)
+	}
+	if !reflect.DeepEqual(got, []*Fasta{fa}) {
+		t.Errorf("Reader()=%v, want %v", got, []*Fasta{fa})
+	}
+}
+
+func BenchmarkWriter(b *testing.B) {
+	fa := &Fasta{Name: []byte("bla bla bla bla bla bla"),
+		Sequence: bytes.Repeat([]byte("a"), 1000)}
+	w := NewWriter(io.Discard, 60)
+	for b.Loop() {
+		w.Write(fa)
+	}
+}
@@ -0,0 +1,213 @@
+// Barcode demultiplexing.
+
+package fastq
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// A Sample is a line in a barcode sheet.
+type Sample struct {
+	Name    string // Sample name
+	Barcode string // Barcode sequence, such as "ACGTACGT" or "ACGT+TTGA"
+}
+
+// ReadBarcodeSheet reads a barcode sheet from r. Each line should have a
+// sample name and a barcode, separated by whitespace. Empty lines and lines
+// that start with '#' are skipped.
+func ReadBarcodeSheet(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	sc := bufio.NewScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %v: bad number of fields: %v, "+
+				"want 2", lineNum, len(fields))
+		}
+		samples = append(samples, Sample{fields[0], fields[1]})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// A Demuxer assigns reads to samples by their barcodes.
+type Demuxer struct {
+	samples    []Sample
+	exact      map[string]int // Sample index by barcode
+	mismatches int
+	inline     bool
+}
+
+// NewDemuxer returns a demuxer for the given samples, which allows up to the
+// given number of mismatches between a read's barcode and a sample's barcode.
+// If inline is true, barcodes are taken from the start of the reads (the first
+// mate in pairs) and removed from them. Otherwise, barcodes are the index
+// sequences in the read names, as parsed by ParseIllumina.
+//
+// Returns an error if barcodes have different lengths, or if two barcodes
+// differ in at most 2*mismatches positions, so a read's barcode could match
+// both.
+func NewDemuxer(samples []Sample, mismatches int,
+	inline bool) (*Demuxer, error) {
+	if mismatches < 0 {
+		return nil, fmt.Errorf("bad number of mismatches: %v", mismatches)
+	}
+	exact := make(map[string]int, len(samples))
+	names := make(map[string]bool, len(samples))
+	for i, s := range samples {
+		if names[s.Name] {
+			return nil, fmt.Errorf("duplicate sample name: %q", s.Name)
+		}
+		names[s.Name] = true
+		if s.Barcode == "" {
+			return nil, fmt.Errorf("sample %q has an empty barcode", s.Name)
+		}
+		if len(s.Barcode) != len(samples[0].Barcode) {
+			return nil, fmt.Errorf("sample %q has barcode length %v, want %v",
+				s.Name, len(s.Barcode), len(samples[0].Barcode))
+		}
+		for _, t := range samples[:i] {
+			if withinHamming([]byte(s.Barcode), []byte(t.Barcode),
+				2*mismatches) {
+				return nil, fmt.Errorf("barcodes of samples %q and %q are "+
+					"not uniquely decodable with %v mismatches: %q, %q",
+					t.Name, s.Name, mismatches, t.Barcode, s.Barcode)
+			}
+		}
+		exact[s.Barcode] = i
+	}
+	return &Demuxer{samples, exact, mismatches, inline}, nil
+}
+
+// Assign returns the index of the sample of fq, or -1 if no sample matches.
+// For inline barcodes, the barcode is removed from fq if a sample matches.
+func (d *Demuxer) Assign(fq *Fastq) (int, error) {
+	if len(d.samples) == 0 {
+		return -1, nil
+	}
+	var barcode []byte
+	if d.inline {
+		n := len(d.samples[0].Barcode)
+		if len(fq.Sequence) < n {
+			return -1, nil
+		}
+		barcode = fq.Sequence[:n]
+	} else {
+		h, err := ParseIllumina(fq.Name)
+		if err != nil {
+			return -1, err
+		}
+		barcode = []byte(h.Index)
+	}
+
+	i := d.match(barcode)
+	if i != -1 && d.inline {
+		fq.slice(len(barcode), len(fq.Sequence))
+	}
+	return i, nil
+}
+
+// Returns the index of the sample that matches the given barcode, or -1.
+func (d *Demuxer) match(barcode []byte) int {
+	if i, ok := d.exact[string(barcode)]; ok {
+		return i
+	}
+	if d.mismatches == 0 {
+		return -1
+	}
+	for i, s := range d.samples {
+		if withinHamming([]byte(s.Barcode), barcode, d.mismatches) {
+			return i
+		}
+	}
+	return -1
+}
+
+// DemuxCounts holds the number of reads (or pairs) of each sample.
+type DemuxCounts struct {
+	Samples      []int // Counts by sample index
+	Undetermined int   // Reads that matched no sample
+}
+
+// Demux writes each read to the writer of its sample, where out[i] is the
+// writer of sample i. Reads that match no sample are written to undetermined,
+// or discarded if it is nil. Stops at the first error.
+func (d *Demuxer) Demux(reads iter.Seq2[*Fastq, error], out []io.Writer,
+	undetermined io.Writer) (*DemuxCounts, error) {
+	if len(out) != len(d.samples) {
+		return nil, fmt.Errorf("got %v writers for %v samples",
+			len(out), len(d.samples))
+	}
+	counts := &DemuxCounts{Samples: make([]int, len(d.samples))}
+	for fq, err := range reads {
+		if err != nil {
+			return counts, err
+		}
+		i, err := d.Assign(fq)
+		if err != nil {
+			return counts, err
+		}
+		w := undetermined
+		if i == -1 {
+			counts.Undetermined++
+		} else {
+			counts.Samples[i]++
+			w = out[i]
+		}
+		if w == nil {
+			continue
+		}
+		if err := fq.Write(w); err != nil {
+			return counts, err
+		}
+	}
+	return counts, nil
+}
+
+// DemuxPaired writes each pair to the writers of its sample, where out[i]
+// holds the writers of the first and second mates of sample i. Pairs are
+// assigned by their first mates. Pairs that match no sample are written to
+// undetermined, or discarded if it has nil writers. Stops at the first error.
+func (d *Demuxer) DemuxPaired(pairs iter.Seq2[[]*Fastq, error],
+	out [][2]io.Writer, undetermined [2]io.Writer) (*DemuxCounts, error) {
+	if len(out) != len(d.samples) {
+		return nil, fmt.Errorf("got %v writers for %v samples",
+			len(out), len(d.samples))
+	}
+	counts := &DemuxCounts{Samples: make([]int, len(d.samples))}
+	for pair, err := range pairs {
+		if err != nil {
+			return counts, err
+		}
+		i, err := d.Assign(pair[0])
+		if err != nil {
+			return counts, err
+		}
+		w := undetermined
+		if i == -1 {
+			counts.Undetermined++
+		} else {
+			counts.Samples[i]++
+			w = out[i]
+		}
+		for j, fq := range pair {
+			if w[j] == nil {
+				continue
+			}
+			if err := fq.Write(w[j]); err != nil {
+				return counts, err
+			}
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,147 @@
+package fastq
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadBarcodeSheet(t *testing.T) {
+	input := "# name barcode\ns1\tACGT\n\n  s2   TTGA+CCAA \n"
+	want := []Sample{{"s1", "ACGT"}, {"s2", "TTGA+CCAA"}}
+	got, err := ReadBarcodeSheet(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadBarcodeSheet(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadBarcodeSheet(%q)=%v, want %v", input, got, want)
+	}
+	if got, err := ReadBarcodeSheet(strings.NewReader("s1 AC GT")); err == nil {
+		t.Fatalf("ReadBarcodeSheet(%q)=%v, want error", "s1 AC GT", got)
+	}
+}
+
+func TestNewDemuxer_bad(t *testing.T) {
+	tests := []struct {
+		samples    []Sample
+		mismatches int
+	}{
+		{[]Sample{{"a", "AAAA"}, {"a", "CCCC"}}, 0},
+		{[]Sample{{"a", "AAAA"}, {"b", "CCC"}}, 0},
+		{[]Sample{{"a", "AAAA"}, {"b", ""}}, 0},
+		{[]Sample{{"a", "AAAA"}, {"b", "AAAA"}}, 0},
+		{[]Sample{{"a", "AAAA"}, {"b", "AACC"}}, 1},
+		{[]Sample{{"a", "AAAA"}, {"b", "AAAC"}}, -1},
+	}
+	for _, test := range tests {
+		if _, err := NewDemuxer(test.samples, test.mismatches,
+			true); err == nil {
+			t.Errorf("NewDemuxer(%v,%v) succeeded, want error",
+				test.samples, test.mismatches)
+		}
+	}
+	if _, err := NewDemuxer([]Sample{{"a", "AAAA"}, {"b", "ACCC"}}, 1,
+		true); err != nil {
+		t.Errorf("NewDemuxer(...) failed: %v", err)
+	}
+}
+
+func TestDemuxer_Assign(t *testing.T) {
+	samples := []Sample{{"a", "AAAA"}, {"b", "CCCC"}}
+	d, err := NewDemuxer(samples, 1, true)
+	if err != nil {
+		t.Fatalf("NewDemuxer(%v) failed: %v", samples, err)
+	}
+	tests := []struct {
+		seq, wantSeq string
+		want         int
+	}{
+		{"AAAAGT", "GT", 0},
+		{"CCCCGT", "GT", 1},
+		{"ACCCGT", "GT", 1},
+		{"ANAAGT", "GT", 0},
+		{"AACCGT", "AACCGT", -1},
+		{"AAA", "AAA", -1},
+	}
+	for _, test := range tests {
+		fq := newTestFastq(test.seq, "")
+		got, err := d.Assign(fq)
+		if err != nil {
+			t.Fatalf("Assign(%q) failed: %v", test.seq, err)
+		}
+		if got != test.want || string(fq.Sequence) != test.wantSeq ||
+			len(fq.Quals) != len(fq.Sequence) {
+			t.Errorf("Assign(%q)=%v,%q, want %v,%q",
+				test.seq, got, fq.Sequence, test.want, test.wantSeq)
+		}
+	}
+}
+
+func TestDemuxer_Demux(t *testing.T) {
+	samples := []Sample{{"a", "ACGT+AAAA"}, {"b", "TTTT+CCCC"}}
+	d, err := NewDemuxer(samples, 1, false)
+	if err != nil {
+		t.Fatalf("NewDemuxer(%v) failed: %v", samples, err)
+	}
+	input := "@r:1:F:1:1:1:1 1:N:0:ACGT+AAAA\nAC\n+\nII\n" +
+		"@r:1:F:1:1:1:2 1:N:0:TTTT+CCCA\nAC\n+\nII\n" +
+		"@r:1:F:1:1:1:3 1:N:0:GGGG+GGGG\nAC\n+\nII\n" +
+		"@r:1:F:1:1:1:4 1:N:0:ACGT+AAAT\nAC\n+\nII\n"
+	outs := []*strings.Builder{{}, {}}
+	undet := &strings.Builder{}
+	counts, err := d.Demux(Reader(strings.NewReader(input)),
+		[]io.Writer{outs[0], outs[1]}, undet)
+	if err != nil {
+		t.Fatalf("Demux(...) failed: %v", err)
+	}
+	want := &DemuxCounts{[]int{2, 1}, 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("Demux(...)=%v, want %v", counts, want)
+	}
+	if !strings.Contains(outs[1].String(), "1:1:2 ") ||
+		!strings.Contains(undet.String(), "1:1:3 ") {
+		t.Fatalf("Demux(...) wrote %q, %q, want reads 2 and 3",
+			outs[1].String(), undet.String())
+	}
+
+	// Names that are not Illumina names.
+	if _, err := d.Demux(Reader(strings.NewReader("@x\nA\n+\nI\n")),
+		[]io.Writer{nil, nil}, nil); err == nil {
+		t.Fatalf("Demux(...) succeeded, want error")
+	}
+}
+
+func TestDemuxer_DemuxPaired(t *testing.T) {
+	samples := []Sample{{"a", "AAA"}, {"b", "CCC"}}
+	d, err := NewDemuxer(samples, 0, true)
+	if err != nil {
+		t.Fatalf("NewDemuxer(%v) failed: %v", samples, err)
+	}
+	input := "@x/1\nAAAGT\n+\nIIIII\n@x/2\nTTTTT\n+\nIIIII\n" +
+		"@y/1\nCCCGT\n+\nIIIII\n@y/2\nGGGGG\n+\nIIIII\n" +
+		"@z/1\nGGGGT\n+\nIIIII\n@z/2\nAAAAA\n+\nIIIII\n"
+	var outs [2][2]strings.Builder
+	counts, err := d.DemuxPaired(ReaderInterleaved(strings.NewReader(input)),
+		[][2]io.Writer{{&outs[0][0], &outs[0][1]}, {&outs[1][0], &outs[1][1]}},
+		[2]io.Writer{})
+	if err != nil {
+		t.Fatalf("DemuxPaired(...) failed: %v", err)
+	}
+	want := &DemuxCounts{[]int{1, 1}, 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("DemuxPaired(...)=%v, want %v", counts, want)
+	}
+	wantOuts := [2][2]string{
+		{"@x/1\nGT\n+\nII\n", "@x/2\nTTTTT\n+\nIIIII\n"},
+		{"@y/1\nGT\n+\nII\n", "@y/2\nGGGGG\n+\nIIIII\n"},
+	}
+	for i := range outs {
+		for j := range outs[i] {
+			if got := outs[i][j].String(); got != wantOuts[i][j] {
+				t.Errorf("DemuxPaired(...) output %v,%v=%q, want %q",
+					i, j, got, wantOuts[i][j])
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Illumina read names.
+
+package fastq
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// IlluminaName holds the fields of an Illumina read name. Fields that are not
+// in the name are left at their zero values.
+type IlluminaName struct {
+	Instrument string // Instrument ID
+	Run        int    // Run number on the instrument (Casava 1.8+)
+	Flowcell   string // Flowcell ID (Casava 1.8+)
+	Lane       int    // Flowcell lane
+	Tile       int    // Tile number within the lane
+	X          int    // X coordinate of the cluster within the tile
+	Y          int    // Y coordinate of the cluster within the tile
+	UMI        string // Unique molecular identifier (Casava 1.8+, optional)
+	Read       int    // Read number in a pair (1 or 2), or 0 if not given
+	Filtered   bool   // Whether the read was filtered (Casava 1.8+)
+	Control    int    // Control number, 0 when none (Casava 1.8+)
+	Index      string // Index sequence, or number in older names
+}
+
+// ParseIllumina parses an Illumina read name, without the '@'. Supports the
+// Casava 1.8+ format:
+//
+//	instrument:run:flowcell:lane:tile:x:y[:umi] read:filtered:control:index
+//
+// And the format of older pipelines:
+//
+//	instrument:lane:tile:x:y[#index][/read]
+//
+// The comment part of Casava 1.8+ names is optional. As in MateName, it is
+// separated from the ID by the first space or tab.
+func ParseIllumina(name []byte) (*IlluminaName, error) {
+	id, comment := splitName(name)
+	hasComment := comment != nil
+	if hasComment {
+		comment = comment[1:]
+	}
+	fields := bytes.Split(id, []byte(":"))
+	var result *IlluminaName
+	var err error
+	switch len(fields) {
+	case 5:
+		if hasComment {
+			return nil, fmt.Errorf("unexpected comment in illumina name: %q",
+				name)
+		}
+		result, err = parseOldIllumina(fields)
+	case 7, 8:
+		result, err = parseCasava(fields, comment, hasComment)
+	default:
+		err = fmt.Errorf("bad number of fields: %v, want 5, 7 or 8",
+			len(fields))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bad illumina name %q: %w", name, err)
+	}
+	return result, nil
+}
+
+// Parses the fields of an older Illumina name.
+func parseOldIllumina(fields [][]byte) (*IlluminaName, error) {
+	last := fields[4]
+	read := 0
+	if i := bytes.LastIndexByte(last, '/'); i != -1 {
+		r, err := parseIlluminaRead(last[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		read = r
+		last = last[:i]
+	}
+	last, index, _ := bytes.Cut(last, []byte("#"))
+	fields[4] = last
+
+	var nums [4]int
+	for i := range nums {
+		x, err := parseIlluminaInt(fields[i+1])
+		if err != nil {
+			return nil, err
+		}
+		nums[i] = x
+	}
+	return &IlluminaName{
+		Instrument: string(fields[0]),
+		Lane:       nums[0],
+		Tile:       nums[1],
+		X:          nums[2],
+		Y:          nums[3],
+		Read:       read,
+		Index:      string(index),
+	}, nil
+}
+
+// Parses the fields of a Casava 1.8+ name.
+func parseCasava(fields [][]byte, comment []byte,
+	hasComment bool) (*IlluminaName, error) {
+	var nums [5]int
+	for i, j := range []int{1, 3, 4, 5, 6} {
+		x, err := parseIlluminaInt(fields[j])
+		if err != nil {
+			return nil, err
+		}
+		nums[i] = x
+	}
+	result := &IlluminaName{
+		Instrument: string(fields[0]),
+		Run:        nums[0],
+		Flowcell:   string(fields[2]),
+		Lane:       nums[1],
+		Tile:       nums[2],
+		X:          nums[3],
+		Y:          nums[4],
+	}
+	if len(fields) == 8 {
+		result.UMI = string(fields[7])
+	}
+	if !hasComment {
+		return result, nil
+	}
+
+	// Ignore anything after the comment, such as additional comments.
+	if i := bytes.IndexAny(comment, " \t"); i != -1 {
+		comment = comment[:i]
+	}
+	cfields := bytes.Split(comment, []byte(":"))
+	if len(cfields) != 4 {
+		return nil, fmt.Errorf("bad number of comment fields: %v, want 4",
+			len(cfields))
+	}
+	read, err := parseIlluminaRead(cfields[0])
+	if err != nil {
+		return nil, err
+	}
+	result.Read = read
+	switch string(cfields[1]) {
+	case "Y":
+		result.Filtered = true
+	case "N":
+	default:
+		return nil, fmt.Errorf("bad filter flag: %q, want Y or N", cfields[1])
+	}
+	if result.Control, err = parseIlluminaInt(cfields[2]); err != nil {
+		return nil, err
+	}
+	result.Index = string(cfields[3])
+	return result, nil
+}
+
+// Parses a non-negative integer field.
+func parseIlluminaInt(b []byte) (int, error) {
+	x, err := strconv.Atoi(string(b))
+	if err != nil || x < 0 {
+		return 0, fmt.Errorf("bad number: %q", b)
+	}
+	return x, nil
+}
+
+// Parses a read number field.
+func parseIlluminaRead(b []byte) (int, error) {
+	x, err := parseIlluminaInt(b)
+	if err != nil || x < 1 {
+		return 0, fmt.Errorf("bad read number: %q", b)
+	}
+	return x, nil
+}
@@ -0,0 +1,69 @@
+package fastq
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIllumina(t *testing.T) {
+	tests := []struct {
+		name string
+		want *IlluminaName
+	}{
+		{"EAS139:136:FC706VJ:2:2104:15343:197393 1:Y:18:ATCACG",
+			&IlluminaName{Instrument: "EAS139", Run: 136, Flowcell: "FC706VJ",
+				Lane: 2, Tile: 2104, X: 15343, Y: 197393, Read: 1,
+				Filtered: true, Control: 18, Index: "ATCACG"}},
+		{"EAS139:136:FC706VJ:2:2104:15343:197393\t1:Y:18:ATCACG",
+			&IlluminaName{Instrument: "EAS139", Run: 136, Flowcell: "FC706VJ",
+				Lane: 2, Tile: 2104, X: 15343, Y: 197393, Read: 1,
+				Filtered: true, Control: 18, Index: "ATCACG"}},
+		{"A00123:8:H5KJ2DSXX:1:1101:1000:2000:ACGTACGT 2:N:0:ACGT+TTGA",
+			&IlluminaName{Instrument: "A00123", Run: 8, Flowcell: "H5KJ2DSXX",
+				Lane: 1, Tile: 1101, X: 1000, Y: 2000, UMI: "ACGTACGT",
+				Read: 2, Index: "ACGT+TTGA"}},
+		{"M001:1:000000000-A1B2C:1:1101:15589:1331",
+			&IlluminaName{Instrument: "M001", Run: 1,
+				Flowcell: "000000000-A1B2C", Lane: 1, Tile: 1101, X: 15589,
+				Y: 1331}},
+		{"HWUSI-EAS100R:6:73:941:1973#0/1",
+			&IlluminaName{Instrument: "HWUSI-EAS100R", Lane: 6, Tile: 73,
+				X: 941, Y: 1973, Read: 1, Index: "0"}},
+		{"HWUSI-EAS100R:6:73:941:1973#ATCACG/2",
+			&IlluminaName{Instrument: "HWUSI-EAS100R", Lane: 6, Tile: 73,
+				X: 941, Y: 1973, Read: 2, Index: "ATCACG"}},
+		{"HWUSI-EAS100R:6:73:941:1973",
+			&IlluminaName{Instrument: "HWUSI-EAS100R", Lane: 6, Tile: 73,
+				X: 941, Y: 1973}},
+	}
+	for _, test := range tests {
+		got, err := ParseIllumina([]byte(test.name))
+		if err != nil {
+			t.Fatalf("ParseIllumina(%q) failed: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("ParseIllumina(%q)=%+v, want %+v",
+				test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseIllumina_bad(t *testing.T) {
+	names := []string{
+		"",
+		"read1",
+		"EAS139:136:FC706VJ:2:2104:15343",
+		"EAS139:136:FC706VJ:2:2104:15343:197393 1:Y:18",
+		"EAS139:136:FC706VJ:2:2104:15343:197393 1:X:18:ATCACG",
+		"EAS139:136:FC706VJ:2:2104:15343:197393 0:N:18:ATCACG",
+		"EAS139:136:FC706VJ:2:2104:x:197393 1:N:18:ATCACG",
+		"EAS139:136:FC706VJ:-2:2104:15343:197393",
+		"HWUSI-EAS100R:6:73:941:1973#0/a",
+		"HWUSI-EAS100R:6:73:941:1973/1 comment",
+	}
+	for _, name := range names {
+		if got, err := ParseIllumina([]byte(name)); err == nil {
+			t.Errorf("ParseIllumina(%q)=%+v, want error", name, got)
+		}
+	}
+}
@@ -56,7 +56,8 @@ func Reader(r io.Reader) iter.Seq2[*Fastq, error] {
 
 // FilePaired returns an iterator over fastq entries in two paired files.
 // Yields pairs of entries, one from each file.
-// The files are expected to have the same number of reads.
+// The files are expected to have the same number of reads. Mate names are not
+// checked; use CheckPairs for that.
 func FilePaired(file1, file2 string) iter.Seq2[[]*Fastq, error] {
 	return func(yield func([]*Fastq, error) bool) {
 		next1, stop1 := iter.Pull2(File(file1))
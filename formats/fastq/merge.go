@@ -0,0 +1,161 @@
+// Paired-end read merging.
+
+package fastq
+
+import (
+	"iter"
+	"math"
+	"slices"
+
+	"github.com/fluhus/biostuff/sequtil"
+)
+
+// A Merger merges overlapping mates into single reads, as in FLASH and PEAR.
+// The zero value is not usable; set at least MinOverlap and Offset.
+type Merger struct {
+	MinOverlap      int     // Minimal overlap length
+	MaxMismatchRate float64 // Maximal fraction of mismatches in the overlap
+	Offset          int     // Quality offset
+	MaxQual         int     // Maximal merged Phred score, or 0 for 41
+}
+
+// Merge returns the merge of fq1 with the reverse complement of fq2, or nil if
+// they do not overlap by at least MinOverlap bases with at most
+// MaxMismatchRate mismatches. N bases do not count as mismatches. Of all
+// valid overlaps, the one with the lowest mismatch rate is chosen, preferring
+// longer overlaps on ties.
+//
+// Overlaps where fq2 extends past the start of fq1 are considered too, as
+// when the insert is shorter than the reads. In that case, only the overlap is
+// kept, since the bases outside it are adapter sequence. Otherwise, the merged
+// read spans from the start of fq1 to the start of fq2, which are the ends of
+// the fragment. Bases of fq1 past the start of fq2 are adapter sequence too,
+// and are dropped.
+//
+// In the overlap, disagreeing bases are resolved by taking the base with the
+// higher quality. Merged qualities are the posterior probabilities of the
+// merged bases, as described in:
+//
+// Edgar RC, Flyvbjerg H. Error filtering, pair assembly and error correction
+// for next-generation sequencing reads. Bioinformatics. 2015.
+//
+// The merged read is named after the mate name of fq1, as returned by
+// MateName. Sequences should contain only characters in "aAcCgGtTnN".
+func (m Merger) Merge(fq1, fq2 *Fastq) *Fastq {
+	seq1, q1 := fq1.Sequence, fq1.Quals
+	seq2 := sequtil.ReverseComplement(nil, fq2.Sequence)
+	q2 := slices.Clone(fq2.Quals)
+	slices.Reverse(q2)
+
+	// Find the best shift, which is the position of seq2 relative to seq1.
+	n1, n2 := len(seq1), len(seq2)
+	bestShift, bestLen, bestRate := 0, 0, math.Inf(1)
+	for shift := -(n2 - m.MinOverlap); shift <= n1-m.MinOverlap; shift++ {
+		from, to := max(shift, 0), min(n1, shift+n2)
+		overlap := to - from
+		if overlap < max(m.MinOverlap, 1) {
+			continue
+		}
+		mismatches := 0
+		for i := from; i < to; i++ {
+			if mismatch(seq1[i], seq2[i-shift]) {
+				mismatches++
+			}
+		}
+		rate := float64(mismatches) / float64(overlap)
+		if rate > m.MaxMismatchRate {
+			continue
+		}
+		if rate < bestRate || rate == bestRate && overlap > bestLen {
+			bestShift, bestLen, bestRate = shift, overlap, rate
+		}
+	}
+	if bestLen == 0 {
+		return nil
+	}
+
+	shift := bestShift
+	from, to := max(shift, 0), min(n1, shift+n2)
+	maxQual := m.MaxQual
+	if maxQual == 0 {
+		maxQual = 41
+	}
+	result := &Fastq{Name: slices.Clone(MateName(fq1.Name))}
+	if shift > 0 {
+		result.Sequence = append(result.Sequence, seq1[:shift]...)
+		result.Quals = append(result.Quals, q1[:shift]...)
+	}
+	for i := from; i < to; i++ {
+		b, q := m.mergeBase(seq1[i], seq2[i-shift], q1[i], q2[i-shift],
+			maxQual)
+		result.Sequence = append(result.Sequence, b)
+		result.Quals = append(result.Quals, q)
+	}
+	if shift >= 0 { // Add the tail of fq2, if it extends past fq1.
+		result.Sequence = append(result.Sequence, seq2[to-shift:]...)
+		result.Quals = append(result.Quals, q2[to-shift:]...)
+	}
+	return result
+}
+
+// Returns whether a and b are different bases, ignoring case and N.
+func mismatch(a, b byte) bool {
+	a, b = upper(a), upper(b)
+	return a != b && a != 'N' && b != 'N'
+}
+
+// Returns the merged base and quality character of two overlapping bases.
+func (m Merger) mergeBase(b1, b2, c1, c2 byte, maxQual int) (byte, byte) {
+	n1, n2 := upper(b1) == 'N', upper(b2) == 'N'
+	switch {
+	case n1 && n2:
+		return b1, min(c1, c2)
+	case n2:
+		return b1, c1
+	case n1:
+		return b2, c2
+	}
+
+	p1 := ErrorProb(int(c1) - m.Offset)
+	p2 := ErrorProb(int(c2) - m.Offset)
+	var b byte
+	var p float64
+	if upper(b1) == upper(b2) {
+		b = b1
+		p = (p1 * p2 / 3) / (1 - p1 - p2 + 4*p1*p2/3)
+	} else {
+		if c1 < c2 { // Take the base with the higher quality.
+			b1, p1, p2 = b2, p2, p1
+		}
+		b = b1
+		p = p1 * (1 - p2/3) / (p1 + p2 - 4*p1*p2/3)
+	}
+	q := min(maxQual, PhredFromProb(min(p, 1)))
+	return b, byte(q + m.Offset)
+}
+
+// A MergeResult is a pair of mates and their merge.
+type MergeResult struct {
+	Pair   []*Fastq // The original mates
+	Merged *Fastq   // The merged read, or nil if the mates were not merged
+}
+
+// MergeAll returns an iterator over the results of merging the given pairs,
+// such as those of FilePaired. Errors are passed on as they are.
+func (m Merger) MergeAll(
+	pairs iter.Seq2[[]*Fastq, error]) iter.Seq2[*MergeResult, error] {
+	return func(yield func(*MergeResult, error) bool) {
+		for pair, err := range pairs {
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+			r := &MergeResult{pair, m.Merge(pair[0], pair[1])}
+			if !yield(r, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package fastq
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fluhus/biostuff/sequtil"
+)
+
+func TestMerger_Merge(t *testing.T) {
+	insert := "ACGTTGCAAGGCTTAACCGGATCCAGTCAT"
+	tests := []struct {
+		seq1, seq2, qual2 string
+		want, wantQuals   string
+	}{
+		{ // Simple overlap.
+			insert[:20], sequtil.ReverseComplementString(insert[10:]),
+			"", insert, strings.Repeat("I", 10) + strings.Repeat("J", 10) +
+				strings.Repeat("I", 10)},
+		{ // Low quality mismatch.
+			insert[:20], sequtil.ReverseComplementString(
+				insert[10:15] + "G" + insert[16:]), // A->G
+			strings.Repeat("I", 14) + "#" + strings.Repeat("I", 5),
+			insert, strings.Repeat("I", 10) + "JJJJJHJJJJ" +
+				strings.Repeat("I", 10)},
+		{ // Short insert.
+			insert[:15] + "AGATC", sequtil.ReverseComplementString(
+				"GCGTA" + insert[:15]),
+			"", insert[:15], strings.Repeat("J", 15)},
+		{ // Read 2 ends inside read 1, so the rest of read 1 is adapter.
+			insert, sequtil.ReverseComplementString(insert[5:20]),
+			"", insert[:20], strings.Repeat("I", 5) + strings.Repeat("J", 15)},
+		{ // Ns.
+			insert[:18] + "NN", sequtil.ReverseComplementString(
+				"N" + insert[11:]), "", insert,
+			strings.Repeat("I", 10) + "I" + strings.Repeat("J", 7) + "II" +
+				strings.Repeat("I", 10)},
+	}
+	m := Merger{MinOverlap: 10, MaxMismatchRate: 0.1, Offset: Phred33}
+	for _, test := range tests {
+		fq1 := newTestFastq(test.seq1, "")
+		fq1.Name = []byte("read/1")
+		fq2 := newTestFastq(test.seq2, test.qual2)
+		got := m.Merge(fq1, fq2)
+		if got == nil {
+			t.Errorf("Merge(%q,%q) failed", test.seq1, test.seq2)
+			continue
+		}
+		if string(got.Name) != "read" || string(got.Sequence) != test.want ||
+			string(got.Quals) != test.wantQuals {
+			t.Errorf("Merge(%q,%q)=%q,%q,%q, want %q,%q,%q",
+				test.seq1, test.seq2, got.Name, got.Sequence, got.Quals,
+				"read", test.want, test.wantQuals)
+		}
+	}
+}
+
+func TestMerger_Merge_none(t *testing.T) {
+	insert := "ACGTTGCAAGGCTTAACCGGATCCAGTCAT"
+	m := Merger{MinOverlap: 10, MaxMismatchRate: 0.1, Offset: Phred33}
+	tests := [][2]string{
+		{strings.Repeat("A", 20), strings.Repeat("A", 20)},
+		{insert[:20], sequtil.ReverseComplementString(insert[11:])},
+		{insert[:20], sequtil.ReverseComplementString(
+			insert[10:12] + "AA" + insert[14:])},
+	}
+	for _, test := range tests {
+		got := m.Merge(newTestFastq(test[0], ""), newTestFastq(test[1], ""))
+		if got != nil {
+			t.Errorf("Merge(%q,%q)=%q, want nil", test[0], test[1],
+				got.Sequence)
+		}
+	}
+}
+
+func TestMerger_MergeAll(t *testing.T) {
+	input := "@a/1\nACGTTGCAAGGCTTAACCGG\n+\nIIIIIIIIIIIIIIIIIIII\n" +
+		"@a/2\nATGACTGGATCCGGTTAAGC\n+\nIIIIIIIIIIIIIIIIIIII\n" +
+		"@b/1\nAAAAAAAAAAAAAAAAAAAA\n+\nIIIIIIIIIIIIIIIIIIII\n" +
+		"@b/2\nAAAAAAAAAAAAAAAAAAAA\n+\nIIIIIIIIIIIIIIIIIIII\n"
+	m := Merger{MinOverlap: 10, Offset: Phred33}
+	var merged, unmerged []string
+	for r, err := range m.MergeAll(ReaderInterleaved(
+		strings.NewReader(input))) {
+		if err != nil {
+			t.Fatalf("MergeAll(...) failed: %v", err)
+		}
+		if r.Merged != nil {
+			merged = append(merged, string(r.Merged.Sequence))
+		} else {
+			unmerged = append(unmerged, string(r.Pair[0].Name))
+		}
+	}
+	if len(merged) != 1 || merged[0] != "ACGTTGCAAGGCTTAACCGGATCCAGTCAT" {
+		t.Errorf("MergeAll(...) merged %q, want %q", merged,
+			"ACGTTGCAAGGCTTAACCGGATCCAGTCAT")
+	}
+	if len(unmerged) != 1 || unmerged[0] != "b/1" {
+		t.Errorf("MergeAll(...) unmerged %q, want %q", unmerged, "b/1")
+	}
+}
@@ -0,0 +1,178 @@
+// Paired-end reads.
+
+package fastq
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/fluhus/gostuff/aio"
+)
+
+// MateName returns the part of a read name that is shared by both mates of a
+// pair. Removes the comment after the first whitespace, such as the Casava 1.8
+// "1:N:0:ATCACG", and then a "/1" or "/2" suffix. The result is a slice of
+// name.
+func MateName(name []byte) []byte {
+	name, _ = splitName(name)
+	if n := len(name); n >= 2 && name[n-2] == '/' &&
+		(name[n-1] == '1' || name[n-1] == '2') {
+		name = name[:n-2]
+	}
+	return name
+}
+
+// CheckMates returns an error if fq1 and fq2 do not have the same mate name,
+// as returned by MateName.
+func CheckMates(fq1, fq2 *Fastq) error {
+	if !bytes.Equal(MateName(fq1.Name), MateName(fq2.Name)) {
+		return fmt.Errorf("mate names don't match: %q and %q",
+			fq1.Name, fq2.Name)
+	}
+	return nil
+}
+
+// CheckPairs returns an iterator over the given pairs that stops with an error
+// if the mates of a pair do not match, as checked by CheckMates. Errors are
+// passed on as they are.
+func CheckPairs(pairs iter.Seq2[[]*Fastq, error]) iter.Seq2[[]*Fastq, error] {
+	return func(yield func([]*Fastq, error) bool) {
+		for pair, err := range pairs {
+			if err == nil {
+				err = CheckMates(pair[0], pair[1])
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(pair, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ReaderInterleaved returns an iterator over pairs of entries in interleaved
+// fastq data, where each entry is followed by its mate. Returns an error if
+// mate names don't match, or if the number of entries is odd.
+func ReaderInterleaved(r io.Reader) iter.Seq2[[]*Fastq, error] {
+	return func(yield func([]*Fastq, error) bool) {
+		var fq1 *Fastq
+		for fq, err := range Reader(r) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if fq1 == nil {
+				fq1 = fq
+				continue
+			}
+			if err := CheckMates(fq1, fq); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield([]*Fastq{fq1, fq}, nil) {
+				return
+			}
+			fq1 = nil
+		}
+		if fq1 != nil {
+			yield(nil, fmt.Errorf("entry %q has no mate", fq1.Name))
+		}
+	}
+}
+
+// FileInterleaved returns an iterator over pairs of entries in an interleaved
+// fastq file, where each entry is followed by its mate. Returns an error if
+// mate names don't match, or if the number of entries is odd.
+func FileInterleaved(file string) iter.Seq2[[]*Fastq, error] {
+	return func(yield func([]*Fastq, error) bool) {
+		f, err := aio.Open(file)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer f.Close()
+		for pair, err := range ReaderInterleaved(f) {
+			if !yield(pair, err) {
+				break
+			}
+		}
+	}
+}
+
+// WriteInterleaved writes the given pairs to w in interleaved format, where
+// each entry is followed by its mate. Stops at the first error.
+func WriteInterleaved(w io.Writer, pairs iter.Seq2[[]*Fastq, error]) error {
+	for pair, err := range pairs {
+		if err != nil {
+			return err
+		}
+		if err := pair[0].Write(w); err != nil {
+			return err
+		}
+		if err := pair[1].Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePairs writes the first mate of each pair to w1 and the second to w2.
+// Stops at the first error.
+func WritePairs(w1, w2 io.Writer, pairs iter.Seq2[[]*Fastq, error]) error {
+	for pair, err := range pairs {
+		if err != nil {
+			return err
+		}
+		if err := pair[0].Write(w1); err != nil {
+			return err
+		}
+		if err := pair[1].Write(w2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SplitFile writes the pairs of the interleaved file in to the files out1 and
+// out2, one mate in each.
+func SplitFile(in, out1, out2 string) error {
+	f1, err := aio.Create(out1)
+	if err != nil {
+		return err
+	}
+	f2, err := aio.Create(out2)
+	if err != nil {
+		f1.Close()
+		return err
+	}
+	if err := WritePairs(f1, f2, FileInterleaved(in)); err != nil {
+		f1.Close()
+		f2.Close()
+		return err
+	}
+	if err := f1.Close(); err != nil {
+		f2.Close()
+		return err
+	}
+	return f2.Close()
+}
+
+// MergeFiles writes the pairs of the files in1 and in2 to the file out in
+// interleaved format. Returns an error if mate names don't match, as in
+// CheckPairs, so that the output can be read with FileInterleaved.
+func MergeFiles(in1, in2, out string) error {
+	f, err := aio.Create(out)
+	if err != nil {
+		return err
+	}
+	err = WriteInterleaved(f, CheckPairs(FilePaired(in1, in2)))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,174 @@
+package fastq
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMateName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"read1", "read1"},
+		{"read1/1", "read1"},
+		{"read1/2", "read1"},
+		{"read1/3", "read1/3"},
+		{"EAS139:136:FC706VJ:2:2104:15343:197393 1:Y:18:ATCACG",
+			"EAS139:136:FC706VJ:2:2104:15343:197393"},
+		{"read1/1\tcomment", "read1"},
+		{"/1", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := MateName([]byte(test.name)); string(got) != test.want {
+			t.Errorf("MateName(%q)=%q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCheckMates(t *testing.T) {
+	good := [][2]string{
+		{"a/1", "a/2"},
+		{"a 1:N:0:ACGT", "a 2:N:0:ACGT"},
+		{"a", "a"},
+	}
+	for _, names := range good {
+		fq1 := &Fastq{Name: []byte(names[0])}
+		fq2 := &Fastq{Name: []byte(names[1])}
+		if err := CheckMates(fq1, fq2); err != nil {
+			t.Errorf("CheckMates(%q,%q) failed: %v", names[0], names[1], err)
+		}
+	}
+	bad := [][2]string{
+		{"a/1", "b/2"},
+		{"a 1:N:0:ACGT", "b 2:N:0:ACGT"},
+		{"a/1", "a2"},
+	}
+	for _, names := range bad {
+		fq1 := &Fastq{Name: []byte(names[0])}
+		fq2 := &Fastq{Name: []byte(names[1])}
+		if err := CheckMates(fq1, fq2); err == nil {
+			t.Errorf("CheckMates(%q,%q) succeeded, want error",
+				names[0], names[1])
+		}
+	}
+}
+
+func TestReaderInterleaved(t *testing.T) {
+	input := "@a/1\nAC\n+\nII\n@a/2\nGT\n+\nII\n" +
+		"@b/1\nAAA\n+\nIII\n@b/2\nTTT\n+\nIII\n"
+	want := [][]*Fastq{
+		{{[]byte("a/1"), []byte("AC"), []byte("II")},
+			{[]byte("a/2"), []byte("GT"), []byte("II")}},
+		{{[]byte("b/1"), []byte("AAA"), []byte("III")},
+			{[]byte("b/2"), []byte("TTT"), []byte("III")}},
+	}
+	var got [][]*Fastq
+	for pair, err := range ReaderInterleaved(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("ReaderInterleaved(%q) failed: %v", input, err)
+		}
+		got = append(got, pair)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReaderInterleaved(%q)=%v, want %v", input, got, want)
+	}
+
+	out := &strings.Builder{}
+	err := WriteInterleaved(out, ReaderInterleaved(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("WriteInterleaved(...) failed: %v", err)
+	}
+	if out.String() != input {
+		t.Fatalf("WriteInterleaved(...)=%q, want %q", out.String(), input)
+	}
+}
+
+func TestReaderInterleaved_bad(t *testing.T) {
+	inputs := []string{
+		"@a/1\nAC\n+\nII\n@b/2\nGT\n+\nII\n",
+		"@a/1\nAC\n+\nII\n@a/2\nGT\n+\nII\n@b/1\nAC\n+\nII\n",
+	}
+	for _, input := range inputs {
+		var err error
+		for _, err = range ReaderInterleaved(strings.NewReader(input)) {
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			t.Errorf("ReaderInterleaved(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestSplitMergeFiles(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.fq")
+	out1 := filepath.Join(dir, "out1.fq.gz")
+	out2 := filepath.Join(dir, "out2.fq")
+	merged := filepath.Join(dir, "merged.fq")
+	input := "@a/1\nAC\n+\nII\n@a/2\nGT\n+\nII\n" +
+		"@b 1:N:0:A\nAAA\n+\nIII\n@b 2:N:0:A\nTTT\n+\nIII\n"
+	if err := os.WriteFile(in, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SplitFile(in, out1, out2); err != nil {
+		t.Fatalf("SplitFile(...) failed: %v", err)
+	}
+	var names []string
+	for fq, err := range File(out1) {
+		if err != nil {
+			t.Fatalf("File(%q) failed: %v", out1, err)
+		}
+		names = append(names, string(fq.Name))
+	}
+	want := []string{"a/1", "b 1:N:0:A"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("File(%q) names=%q, want %q", out1, names, want)
+	}
+
+	if err := MergeFiles(out1, out2, merged); err != nil {
+		t.Fatalf("MergeFiles(...) failed: %v", err)
+	}
+	got, err := os.ReadFile(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != input {
+		t.Fatalf("MergeFiles(...)=%q, want %q", got, input)
+	}
+}
+
+func TestCheckPairs(t *testing.T) {
+	dir := t.TempDir()
+	file1 := filepath.Join(dir, "1.fq")
+	file2 := filepath.Join(dir, "2.fq")
+	if err := os.WriteFile(file1, []byte("@a/1\nAC\n+\nII\n"),
+		0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file2, []byte("@b/2\nAC\n+\nII\n"),
+		0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range FilePaired(file1, file2) {
+		if err != nil {
+			t.Fatalf("FilePaired(...) failed: %v", err)
+		}
+	}
+	var err error
+	for _, err = range CheckPairs(FilePaired(file1, file2)) {
+	}
+	if err == nil {
+		t.Fatalf("CheckPairs(FilePaired(...)) succeeded, want error")
+	}
+	out := filepath.Join(dir, "out.fq")
+	if err := MergeFiles(file1, file2, out); err == nil {
+		t.Fatalf("MergeFiles(...) succeeded, want error")
+	}
+}
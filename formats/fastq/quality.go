@@ -0,0 +1,216 @@
+// Quality score handling.
+
+package fastq
+
+import (
+	"fmt"
+	"math"
+)
+
+// Offsets of quality characters. A quality character c with offset o
+// represents the Phred score c-o.
+const (
+	Phred33 = 33 // Sanger and Illumina 1.8+
+	Phred64 = 64 // Illumina 1.3 to 1.7
+)
+
+// Highest character that can encode a quality score.
+const maxQualChar = '~'
+
+// DecodeQuals returns the Phred scores of the given quality characters, using
+// the given offset. Returns an error if a character is below the offset or is
+// not printable.
+func DecodeQuals(quals []byte, offset int) ([]int, error) {
+	result := make([]int, len(quals))
+	for i, c := range quals {
+		if int(c) < offset || c > maxQualChar {
+			return nil, fmt.Errorf("bad quality character at position %v: %q "+
+				"with offset %v", i, c, offset)
+		}
+		result[i] = int(c) - offset
+	}
+	return result, nil
+}
+
+// EncodeQuals returns the quality characters of the given Phred scores, using
+// the given offset. Returns an error if a score is negative or too high to be
+// encoded as a printable character.
+func EncodeQuals(scores []int, offset int) ([]byte, error) {
+	result := make([]byte, len(scores))
+	for i, q := range scores {
+		if q < 0 || q+offset > maxQualChar {
+			return nil, fmt.Errorf("bad quality score at position %v: %v "+
+				"with offset %v", i, q, offset)
+		}
+		result[i] = byte(q + offset)
+	}
+	return result, nil
+}
+
+// ErrorProb returns the probability of a base call error that the given Phred
+// score represents, which is 10^(-q/10).
+func ErrorProb(q int) float64 {
+	return math.Pow(10, -float64(q)/10)
+}
+
+// PhredFromProb returns the Phred score of the given error probability, which
+// is -10*log10(p), rounded to the nearest integer. p should be in (0,1].
+func PhredFromProb(p float64) int {
+	if !(p > 0 && p <= 1) {
+		panic(fmt.Sprintf("bad probability: %v, want in (0,1]", p))
+	}
+	return int(math.Round(-10 * math.Log10(p)))
+}
+
+// DetectOffset returns the most likely quality offset of the given reads,
+// either Phred33 or Phred64. Characters below ';' only appear with Phred33,
+// and characters above 'J' rarely appear with Phred33 in short-read data,
+// unless lower characters appear too. If the qualities fit both offsets,
+// returns Phred33 as the common modern encoding.
+//
+// Returns an error if there are no qualities, or if the qualities have
+// characters that cannot be quality characters.
+func DetectOffset(reads []*Fastq) (int, error) {
+	lo, hi := byte(255), byte(0)
+	for _, fq := range reads {
+		for _, c := range fq.Quals {
+			lo = min(lo, c)
+			hi = max(hi, c)
+		}
+	}
+	if lo > hi {
+		return 0, fmt.Errorf("no qualities to detect the offset from")
+	}
+	if lo < Phred33 || hi > maxQualChar {
+		return 0, fmt.Errorf("qualities out of range: %q to %q", lo, hi)
+	}
+	if lo >= ';' && hi > 'J' {
+		return Phred64, nil
+	}
+	return Phred33, nil
+}
+
+// Stats accumulates summary statistics of fastq entries, similar to those of
+// FastQC. Positions are 0-based positions in the reads.
+type Stats struct {
+	Offset  int      // Quality offset
+	Reads   int      // Number of reads
+	Lengths []int    // Number of reads of each length
+	Quals   [][]int  // Number of bases with each Phred score, per position
+	Bases   [256]int // Number of occurrences of each base character
+	GCDist  [101]int // Number of reads of each GC percentage
+}
+
+// NewStats returns an empty accumulator that decodes qualities with the given
+// offset.
+func NewStats(offset int) *Stats {
+	return &Stats{Offset: offset}
+}
+
+// Add adds the given entry to the statistics. Returns an error if the
+// qualities cannot be decoded, in which case the statistics are unchanged.
+func (s *Stats) Add(fq *Fastq) error {
+	if len(fq.Quals) != len(fq.Sequence) {
+		return fmt.Errorf("sequence and qualities have different lengths: "+
+			"%v and %v", len(fq.Sequence), len(fq.Quals))
+	}
+	quals, err := DecodeQuals(fq.Quals, s.Offset)
+	if err != nil {
+		return err
+	}
+
+	s.Reads++
+	n := len(fq.Sequence)
+	for len(s.Lengths) <= n {
+		s.Lengths = append(s.Lengths, 0)
+	}
+	s.Lengths[n]++
+	for len(s.Quals) < n {
+		s.Quals = append(s.Quals, nil)
+	}
+	for i, q := range quals {
+		for len(s.Quals[i]) <= q {
+			s.Quals[i] = append(s.Quals[i], 0)
+		}
+		s.Quals[i][q]++
+	}
+
+	gc, acgt := 0, 0
+	for _, c := range fq.Sequence {
+		s.Bases[c]++
+		switch c {
+		case 'G', 'C', 'g', 'c':
+			gc++
+			acgt++
+		case 'A', 'T', 'a', 't':
+			acgt++
+		}
+	}
+	if acgt > 0 {
+		s.GCDist[int(math.Round(float64(gc)*100/float64(acgt)))]++
+	}
+	return nil
+}
+
+// NumBases returns the total number of bases that were added.
+func (s *Stats) NumBases() int {
+	n := 0
+	for length, count := range s.Lengths {
+		n += length * count
+	}
+	return n
+}
+
+// MeanLength returns the average read length, or NaN if no reads were added.
+func (s *Stats) MeanLength() float64 {
+	return float64(s.NumBases()) / float64(s.Reads)
+}
+
+// GC returns the fraction of G and C out of A, C, G and T bases, or NaN if no
+// such bases were added. Lowercase bases are counted too.
+func (s *Stats) GC() float64 {
+	b := &s.Bases
+	gc := b['G'] + b['C'] + b['g'] + b['c']
+	at := b['A'] + b['T'] + b['a'] + b['t']
+	return float64(gc) / float64(gc+at)
+}
+
+// MeanQuality returns the average Phred score at position i, or NaN if no
+// read reaches that position.
+func (s *Stats) MeanQuality(i int) float64 {
+	if i >= len(s.Quals) {
+		return math.NaN()
+	}
+	sum, n := 0, 0
+	for q, count := range s.Quals[i] {
+		sum += q * count
+		n += count
+	}
+	return float64(sum) / float64(n)
+}
+
+// QualityQuantile returns the Phred score at quantile p of position i, where
+// p is between 0 and 1. For example, p=0.5 returns the median. Returns -1 if
+// no read reaches that position.
+func (s *Stats) QualityQuantile(i int, p float64) int {
+	if p < 0 || p > 1 {
+		panic(fmt.Sprintf("bad quantile: %v, want between 0 and 1", p))
+	}
+	if i >= len(s.Quals) {
+		return -1
+	}
+	n := 0
+	for _, count := range s.Quals[i] {
+		n += count
+	}
+	// Smallest score with at least p*n bases at or below it.
+	target := max(int(math.Ceil(p*float64(n))), 1)
+	sum := 0
+	for q, count := range s.Quals[i] {
+		sum += count
+		if sum >= target {
+			return q
+		}
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,193 @@
+package fastq
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeQuals(t *testing.T) {
+	tests := []struct {
+		quals  string
+		offset int
+		want   []int
+	}{
+		{"", Phred33, []int{}},
+		{"!+5?I", Phred33, []int{0, 10, 20, 30, 40}},
+		{"@JT^h", Phred64, []int{0, 10, 20, 30, 40}},
+	}
+	for _, test := range tests {
+		got, err := DecodeQuals([]byte(test.quals), test.offset)
+		if err != nil {
+			t.Fatalf("DecodeQuals(%q,%v) failed: %v",
+				test.quals, test.offset, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("DecodeQuals(%q,%v)=%v, want %v",
+				test.quals, test.offset, got, test.want)
+		}
+		enc, err := EncodeQuals(got, test.offset)
+		if err != nil {
+			t.Fatalf("EncodeQuals(%v,%v) failed: %v", got, test.offset, err)
+		}
+		if string(enc) != test.quals {
+			t.Fatalf("EncodeQuals(%v,%v)=%q, want %q",
+				got, test.offset, enc, test.quals)
+		}
+	}
+}
+
+func TestDecodeQuals_bad(t *testing.T) {
+	if got, err := DecodeQuals([]byte("II5"), Phred64); err == nil {
+		t.Errorf("DecodeQuals(\"II5\",64)=%v, want error", got)
+	}
+	if got, err := DecodeQuals([]byte("II\x7f"), Phred33); err == nil {
+		t.Errorf("DecodeQuals(\"II\\x7f\",33)=%v, want error", got)
+	}
+	if got, err := EncodeQuals([]int{1, -1}, Phred33); err == nil {
+		t.Errorf("EncodeQuals([1 -1],33)=%q, want error", got)
+	}
+	if got, err := EncodeQuals([]int{70}, Phred64); err == nil {
+		t.Errorf("EncodeQuals([70],64)=%q, want error", got)
+	}
+}
+
+func TestErrorProb(t *testing.T) {
+	tests := []struct {
+		q int
+		p float64
+	}{
+		{0, 1}, {10, 0.1}, {20, 0.01}, {30, 0.001}, {40, 0.0001},
+	}
+	for _, test := range tests {
+		if got := ErrorProb(test.q); math.Abs(got-test.p) > 1e-12 {
+			t.Errorf("ErrorProb(%v)=%v, want %v", test.q, got, test.p)
+		}
+		if got := PhredFromProb(test.p); got != test.q {
+			t.Errorf("PhredFromProb(%v)=%v, want %v", test.p, got, test.q)
+		}
+	}
+	if got := PhredFromProb(0.05); got != 13 {
+		t.Errorf("PhredFromProb(0.05)=%v, want 13", got)
+	}
+}
+
+func TestDetectOffset(t *testing.T) {
+	tests := []struct {
+		quals []string
+		want  int
+	}{
+		{[]string{"IIII", "##,F"}, Phred33},
+		{[]string{"FFFF", "FFF:"}, Phred33},
+		{[]string{"hhhh", "BBhT"}, Phred64},
+		{[]string{"IIII", "hhhh"}, Phred64},
+		{[]string{"IIII", "hhh5"}, Phred33},
+	}
+	for _, test := range tests {
+		var reads []*Fastq
+		for _, q := range test.quals {
+			reads = append(reads, &Fastq{Quals: []byte(q)})
+		}
+		got, err := DetectOffset(reads)
+		if err != nil {
+			t.Fatalf("DetectOffset(%q) failed: %v", test.quals, err)
+		}
+		if got != test.want {
+			t.Errorf("DetectOffset(%q)=%v, want %v", test.quals, got, test.want)
+		}
+	}
+}
+
+func TestDetectOffset_bad(t *testing.T) {
+	inputs := [][]*Fastq{
+		nil,
+		{{Quals: []byte{}}},
+		{{Quals: []byte("II I")}},
+	}
+	for _, input := range inputs {
+		if got, err := DetectOffset(input); err == nil {
+			t.Errorf("DetectOffset(%v)=%v, want error", input, got)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	s := NewStats(Phred33)
+	reads := []*Fastq{
+		{[]byte("a"), []byte("ACGT"), []byte("I5+!")},
+		{[]byte("b"), []byte("GGCN"), []byte("II55")},
+		{[]byte("c"), []byte("AA"), []byte("+I")},
+	}
+	for _, fq := range reads {
+		if err := s.Add(fq); err != nil {
+			t.Fatalf("Add(%v) failed: %v", fq, err)
+		}
+	}
+	if s.Reads != 3 {
+		t.Errorf("Reads=%v, want 3", s.Reads)
+	}
+	if got, want := s.Lengths, []int{0, 0, 1, 0, 2}; !reflect.DeepEqual(
+		got, want) {
+		t.Errorf("Lengths=%v, want %v", got, want)
+	}
+	if got := s.NumBases(); got != 10 {
+		t.Errorf("NumBases()=%v, want 10", got)
+	}
+	if got := s.MeanLength(); math.Abs(got-10.0/3) > 1e-12 {
+		t.Errorf("MeanLength()=%v, want %v", got, 10.0/3)
+	}
+	if got := s.Bases['G']; got != 3 {
+		t.Errorf("Bases['G']=%v, want 3", got)
+	}
+	if got, want := s.GC(), 5.0/9; math.Abs(got-want) > 1e-12 {
+		t.Errorf("GC()=%v, want %v", got, want)
+	}
+	if got := s.GCDist[50]; got != 1 {
+		t.Errorf("GCDist[50]=%v, want 1", got)
+	}
+	if got := s.GCDist[100]; got != 1 {
+		t.Errorf("GCDist[100]=%v, want 1", got)
+	}
+	if got := s.GCDist[0]; got != 1 {
+		t.Errorf("GCDist[0]=%v, want 1", got)
+	}
+
+	wantMeans := []float64{30, 100.0 / 3, 15, 10}
+	for i, want := range wantMeans {
+		if got := s.MeanQuality(i); math.Abs(got-want) > 1e-12 {
+			t.Errorf("MeanQuality(%v)=%v, want %v", i, got, want)
+		}
+	}
+	if got := s.MeanQuality(4); !math.IsNaN(got) {
+		t.Errorf("MeanQuality(4)=%v, want NaN", got)
+	}
+
+	quantiles := []struct {
+		i    int
+		p    float64
+		want int
+	}{
+		{0, 0, 10}, {0, 0.5, 40}, {0, 1, 40},
+		{1, 0.5, 40}, {2, 0.5, 10}, {2, 1, 20},
+		{3, 0.5, 0}, {4, 0.5, -1},
+	}
+	for _, test := range quantiles {
+		if got := s.QualityQuantile(test.i, test.p); got != test.want {
+			t.Errorf("QualityQuantile(%v,%v)=%v, want %v",
+				test.i, test.p, got, test.want)
+		}
+	}
+}
+
+func TestStats_bad(t *testing.T) {
+	s := NewStats(Phred64)
+	if err := s.Add(&Fastq{nil, []byte("AC"), []byte("h5")}); err == nil {
+		t.Errorf("Add(quals=\"h5\") succeeded, want error")
+	}
+	if err := s.Add(&Fastq{nil, []byte("AC"), []byte("h")}); err == nil {
+		t.Errorf("Add(quals=\"h\") succeeded, want error")
+	}
+	if !reflect.DeepEqual(s, NewStats(Phred64)) {
+		t.Errorf("Add changed stats after failing: %v", s)
+	}
+}
@@ -0,0 +1,302 @@
+// Read trimming and filtering.
+
+package fastq
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/fluhus/biostuff/align"
+)
+
+// A Transform trims or filters a single fastq entry. It may modify the entry
+// in place, and returns false if the entry should be dropped.
+//
+// Trimming transforms never drop entries, even if they trim them to length 0.
+// Use MinLength after them to drop short entries.
+type Transform func(fq *Fastq) bool
+
+// Apply returns an iterator over the entries of reads, after applying the given
+// transforms in order. Entries for which a transform returns false are
+// skipped. Errors are passed on as they are.
+func Apply(reads iter.Seq2[*Fastq, error],
+	ts ...Transform) iter.Seq2[*Fastq, error] {
+	return func(yield func(*Fastq, error) bool) {
+		for fq, err := range reads {
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+			if !applyAll(fq, ts) {
+				continue
+			}
+			if !yield(fq, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ApplyPaired returns an iterator over the given pairs, such as those of
+// FilePaired, after applying the given transforms in order to both mates. A
+// pair is skipped if a transform returns false for either mate. Errors are
+// passed on as they are.
+func ApplyPaired(pairs iter.Seq2[[]*Fastq, error],
+	ts ...Transform) iter.Seq2[[]*Fastq, error] {
+	return func(yield func([]*Fastq, error) bool) {
+		for pair, err := range pairs {
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+			if !applyAll(pair[0], ts) || !applyAll(pair[1], ts) {
+				continue
+			}
+			if !yield(pair, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Applies the transforms to fq, stopping at the first that returns false.
+func applyAll(fq *Fastq, ts []Transform) bool {
+	for _, t := range ts {
+		if !t(fq) {
+			return false
+		}
+	}
+	return true
+}
+
+// Keeps only the bases from i to j.
+func (f *Fastq) slice(i, j int) {
+	f.Sequence = f.Sequence[i:j]
+	f.Quals = f.Quals[i:j]
+}
+
+// SlidingWindowTrim returns a transform that scans each read from its start
+// with a window of the given size, and cuts the read where the average Phred
+// score in the window drops below threshold, as in Trimmomatic. Bases at the
+// start of the failing window are kept as long as each of them is at least
+// threshold. Qualities are decoded with the given offset. Reads that are
+// shorter than the window are treated as a single window.
+func SlidingWindowTrim(window, threshold, offset int) Transform {
+	if window < 1 {
+		panic(fmt.Sprintf("bad window size: %v, want at least 1", window))
+	}
+	return func(fq *Fastq) bool {
+		q := fq.Quals
+		w := min(window, len(q))
+		sum := 0
+		for _, c := range q[:w] {
+			sum += int(c) - offset
+		}
+		for i := 0; i+w <= len(q) && w > 0; i++ {
+			if i > 0 {
+				sum += int(q[i+w-1]) - int(q[i-1])
+			}
+			if sum >= threshold*w {
+				continue
+			}
+			j := i
+			for j < i+w && int(q[j])-offset >= threshold {
+				j++
+			}
+			fq.slice(0, j)
+			return true
+		}
+		return true
+	}
+}
+
+// QualityTrim returns a transform that trims the low-quality end of each read,
+// as in BWA's -q option. The read is cut at the position that maximizes the sum
+// of threshold minus the Phred score over the trimmed bases. Qualities are
+// decoded with the given offset.
+func QualityTrim(threshold, offset int) Transform {
+	return func(fq *Fastq) bool {
+		cut := len(fq.Quals)
+		sum, best := 0, 0
+		for i := len(fq.Quals) - 1; i >= 0; i-- {
+			sum += threshold - (int(fq.Quals[i]) - offset)
+			if sum < 0 {
+				break
+			}
+			if sum > best {
+				best, cut = sum, i
+			}
+		}
+		fq.slice(0, cut)
+		return true
+	}
+}
+
+// MottTrim returns a transform that keeps the highest scoring segment of each
+// read, where each base scores limit minus its error probability, using the
+// modified Mott algorithm. Bases with an error probability above limit lower
+// the score. Qualities are decoded with the given offset. Reads with no
+// positive segment are trimmed to length 0.
+func MottTrim(limit float64, offset int) Transform {
+	return func(fq *Fastq) bool {
+		bestFrom, bestTo := 0, 0
+		from, sum, best := 0, 0.0, 0.0
+		for i, c := range fq.Quals {
+			sum += limit - ErrorProb(int(c)-offset)
+			if sum <= 0 {
+				from, sum = i+1, 0
+				continue
+			}
+			if sum > best {
+				best, bestFrom, bestTo = sum, from, i+1
+			}
+		}
+		fq.slice(bestFrom, bestTo)
+		return true
+	}
+}
+
+// AdapterTrim returns a transform that finds the given adapter in each read
+// and removes it along with all bases after it, as in Cutadapt's 3' adapter
+// trimming. The adapter may appear at any position in the read, or partially
+// at its end. An occurrence is accepted if it covers at least minOverlap
+// adapter bases, and has at most maxErrorRate errors (substitutions,
+// insertions and deletions) per covered adapter base.
+//
+// Occurrences are found using semi-global alignment, which scores 1 for a
+// match, -1 for a mismatch and -2 for a gap. Reads and adapters may contain
+// IUPAC codes, in upper or lower case, which match the bases they represent.
+// For example, N matches any base. Other characters match nothing.
+func AdapterTrim(adapter []byte, minOverlap int,
+	maxErrorRate float64) Transform {
+	if minOverlap < 1 {
+		panic(fmt.Sprintf("bad minimal overlap: %v, want at least 1",
+			minOverlap))
+	}
+	al := align.NewSemiGlobalAligner(adapter, adapterMatrix,
+		align.FreeEndA|align.FreeStartB|align.FreeEndB)
+	return func(fq *Fastq) bool {
+		steps, _, bi, _ := al.Align(fq.Sequence)
+		errors := 0
+		i, j := bi, 0 // Positions in the read and adapter.
+		for _, step := range steps {
+			switch step {
+			case align.Match:
+				if !iupacMatch(fq.Sequence[i], adapter[j]) {
+					errors++
+				}
+				i++
+				j++
+			case align.Deletion:
+				errors++
+				j++
+			case align.Insertion:
+				errors++
+				i++
+			}
+		}
+		if j >= minOverlap && float64(errors) <= maxErrorRate*float64(j) {
+			fq.slice(0, bi)
+		}
+		return true
+	}
+}
+
+// Bit masks of the bases that each IUPAC nucleotide code represents, in upper
+// and lower case. Other characters have 0.
+var iupacMasks = func() [256]byte {
+	const a, c, g, t = 1, 2, 4, 8
+	codes := map[byte]byte{
+		'A': a, 'C': c, 'G': g, 'T': t, 'U': t,
+		'R': a | g, 'Y': c | t, 'S': c | g, 'W': a | t, 'K': g | t, 'M': a | c,
+		'B': c | g | t, 'D': a | g | t, 'H': a | c | t, 'V': a | c | g,
+		'N': a | c | g | t,
+	}
+	var masks [256]byte
+	for code, mask := range codes {
+		masks[code] = mask
+		masks[code-'A'+'a'] = mask
+	}
+	return masks
+}()
+
+// Returns whether the IUPAC codes a and b have a base in common.
+func iupacMatch(a, b byte) bool {
+	return iupacMasks[a]&iupacMasks[b] != 0
+}
+
+// Substitution matrix of AdapterTrim. Covers all characters, so that reads
+// with non-IUPAC characters do not make the alignment panic.
+var adapterMatrix = func() align.SubstitutionMatrix {
+	m := align.SubstitutionMatrix{{align.Gap, align.Gap}: 0}
+	for a := range 256 {
+		if a == align.Gap {
+			continue
+		}
+		m[[2]byte{byte(a), align.Gap}] = -2
+		m[[2]byte{align.Gap, byte(a)}] = -2
+		for b := range 256 {
+			if b == align.Gap {
+				continue
+			}
+			if iupacMatch(byte(a), byte(b)) {
+				m[[2]byte{byte(a), byte(b)}] = 1
+			} else {
+				m[[2]byte{byte(a), byte(b)}] = -1
+			}
+		}
+	}
+	return m
+}()
+
+// Returns the upper case of an ASCII letter.
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+// PolyTailTrim returns a transform that removes a run of the given base at the
+// end of each read, if it is at least minLength long. For example,
+// PolyTailTrim('G', 10) removes the poly-G tails of two-color Illumina
+// instruments, and PolyTailTrim('A', 10) removes poly-A tails. The base is
+// matched regardless of case.
+func PolyTailTrim(base byte, minLength int) Transform {
+	base = upper(base)
+	return func(fq *Fastq) bool {
+		i := len(fq.Sequence)
+		for i > 0 && upper(fq.Sequence[i-1]) == base {
+			i--
+		}
+		if len(fq.Sequence)-i >= minLength {
+			fq.slice(0, i)
+		}
+		return true
+	}
+}
+
+// MaxN returns a transform that drops reads with more than maxN N bases,
+// regardless of case.
+func MaxN(maxN int) Transform {
+	return func(fq *Fastq) bool {
+		n := 0
+		for _, c := range fq.Sequence {
+			if upper(c) == 'N' {
+				n++
+			}
+		}
+		return n <= maxN
+	}
+}
+
+// MinLength returns a transform that drops reads shorter than n.
+func MinLength(n int) Transform {
+	return func(fq *Fastq) bool {
+		return len(fq.Sequence) >= n
+	}
+}
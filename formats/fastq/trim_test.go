@@ -0,0 +1,182 @@
+package fastq
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Returns a fastq entry with the given sequence and qualities.
+func newTestFastq(seq, quals string) *Fastq {
+	if quals == "" {
+		quals = strings.Repeat("I", len(seq))
+	}
+	return &Fastq{[]byte("read"), []byte(seq), []byte(quals)}
+}
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		name      string
+		t         Transform
+		seq       string
+		quals     string
+		want      string
+		wantQuals string
+	}{
+		{"window", SlidingWindowTrim(3, 20, Phred33),
+			"ACGTACGTA", "IIIII5!!!", "ACGTAC", "IIIII5"},
+		{"window", SlidingWindowTrim(3, 20, Phred33),
+			"ACGTA", "IIIII", "ACGTA", "IIIII"},
+		{"window", SlidingWindowTrim(10, 20, Phred33),
+			"ACG", "+++", "", ""},
+		{"window", SlidingWindowTrim(10, 20, Phred33), "", "", "", ""},
+		{"quality", QualityTrim(20, Phred33),
+			"ACGTACGT", "IIII+5+!", "ACGT", "IIII"},
+		{"quality", QualityTrim(20, Phred33),
+			"ACGTACGT", "IIII+5II", "ACGTACGT", "IIII+5II"},
+		{"quality", QualityTrim(20, Phred64),
+			"ACGTACGT", "hhhhJTJ@", "ACGT", "hhhh"},
+		{"mott", MottTrim(0.05, Phred33),
+			"ACGTACGT", "!!IIII+!", "GTAC", "IIII"},
+		{"mott", MottTrim(0.05, Phred33), "ACG", "!!!", "", ""},
+		{"adapter", AdapterTrim([]byte("AGATCGGAAG"), 3, 0.1),
+			"ACGTACGTTTAGATCGGAAGCCC", "", "ACGTACGTTT", ""},
+		{"adapter", AdapterTrim([]byte("AGATCGGAAG"), 3, 0.1),
+			"ACGTACGTTTAGATC", "", "ACGTACGTTT", ""},
+		{"adapter", AdapterTrim([]byte("AGATCGGAAG"), 3, 0.1),
+			"ACGTACGTTTAG", "", "ACGTACGTTTAG", ""},
+		{"adapter", AdapterTrim([]byte("AGATCGGAAG"), 3, 0.1),
+			"ACGTACGTTTAGTTCGGAAGCC", "", "ACGTACGTTT", ""},
+		{"adapter", AdapterTrim([]byte("AGATCGGAAG"), 3, 0),
+			"ACGTACGTTTAGTTCGGAAGCC", "", "ACGTACGTTTAGTTCGGAAGCC", ""},
+		{"adapter", AdapterTrim([]byte("AGATCGGAAG"), 3, 0.1),
+			"acgtacgtttagatcggaagccc", "", "acgtacgttt", ""},
+		{"adapter", AdapterTrim([]byte("AGATCGGAAG"), 3, 0.1),
+			"AGATCGGAAGCCC", "", "", ""},
+		{"adapter", AdapterTrim([]byte("AGATCGGAAG"), 3, 0),
+			"ACGTACGTTTAGANCGGAAGCCC", "", "ACGTACGTTT", ""},
+		{"adapter", AdapterTrim([]byte("AGNTCGGRAG"), 3, 0),
+			"ACGTACGTTTAGATCGGAagCCC", "", "ACGTACGTTT", ""},
+		{"adapter", AdapterTrim([]byte("AGATCGGAAG"), 3, 0.1),
+			"ACGT.CGTTTAG.TCGGAAGCCC", "", "ACGT.CGTTT", ""},
+		{"adapter", AdapterTrim([]byte("AGATCGGAAG"), 3, 0),
+			"ACGTACGTTTAG.TCGGAAGCCC", "", "ACGTACGTTTAG.TCGGAAGCCC", ""},
+		{"poly", PolyTailTrim('G', 5), "ACGTGGGGGG", "", "ACGT", ""},
+		{"poly", PolyTailTrim('G', 5), "ACGTGGGG", "", "ACGTGGGG", ""},
+		{"poly", PolyTailTrim('g', 5), "ACGTGGggg", "", "ACGT", ""},
+		{"poly", PolyTailTrim('A', 3), "AAAA", "", "", ""},
+	}
+	for _, test := range tests {
+		fq := newTestFastq(test.seq, test.quals)
+		if test.wantQuals == "" {
+			test.wantQuals = strings.Repeat("I", len(test.want))
+		}
+		if !test.t(fq) {
+			t.Errorf("%s(%q,%q) dropped the read, want kept",
+				test.name, test.seq, test.quals)
+			continue
+		}
+		if string(fq.Sequence) != test.want ||
+			string(fq.Quals) != test.wantQuals {
+			t.Errorf("%s(%q,%q)=%q,%q, want %q,%q",
+				test.name, test.seq, test.quals, fq.Sequence, fq.Quals,
+				test.want, test.wantQuals)
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		t    Transform
+		seq  string
+		want bool
+	}{
+		{"maxn", MaxN(1), "ANNA", false},
+		{"maxn", MaxN(1), "ANA", true},
+		{"maxn", MaxN(1), "AnnA", false},
+		{"maxn", MaxN(0), "", true},
+		{"minlen", MinLength(3), "AC", false},
+		{"minlen", MinLength(3), "ACG", true},
+	}
+	for _, test := range tests {
+		if got := test.t(newTestFastq(test.seq, "")); got != test.want {
+			t.Errorf("%s(%q)=%v, want %v", test.name, test.seq, got, test.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	input := "@a\nACGTGGGGGG\n+\nIIIIIIIIII\n" +
+		"@b\nACGGG\n+\nIIIII\n" +
+		"@c\nACGTACGT\n+\nIIII!!!!\n" +
+		"@d\nACNTANGT\n+\nIIIIIIII\n"
+	want := []*Fastq{
+		{[]byte("a"), []byte("ACGT"), []byte("IIII")},
+		{[]byte("c"), []byte("ACGT"), []byte("IIII")},
+	}
+	var got []*Fastq
+	for fq, err := range Apply(Reader(strings.NewReader(input)),
+		PolyTailTrim('G', 3), QualityTrim(20, Phred33), MaxN(1),
+		MinLength(4)) {
+		if err != nil {
+			t.Fatalf("Apply(...) failed: %v", err)
+		}
+		got = append(got, fq)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Apply(...)=%v, want %v", got, want)
+	}
+}
+
+func TestApply_error(t *testing.T) {
+	input := "@a\nACGT\n+\nIIII\nhello"
+	var errs, reads int
+	for _, err := range Apply(Reader(strings.NewReader(input)), MinLength(1)) {
+		if err != nil {
+			errs++
+		} else {
+			reads++
+		}
+	}
+	if reads != 1 || errs != 1 {
+		t.Fatalf("Apply(...) returned %v reads and %v errors, want 1 and 1",
+			reads, errs)
+	}
+}
+
+func TestApplyPaired(t *testing.T) {
+	pairs := [][]*Fastq{
+		{newTestFastq("ACGTAC", ""), newTestFastq("ACGTAC", "")},
+		{newTestFastq("ACGTAC", ""), newTestFastq("ACG", "")},
+		{newTestFastq("ACG", ""), newTestFastq("ACGTAC", "")},
+		{newTestFastq("ACGTAA", ""), newTestFastq("ACGTAC", "")},
+	}
+	input := func(yield func([]*Fastq, error) bool) {
+		for _, p := range pairs {
+			if !yield(p, nil) {
+				return
+			}
+		}
+		yield(nil, fmt.Errorf("oops"))
+	}
+	var got []string
+	var gotErr error
+	for pair, err := range ApplyPaired(input, PolyTailTrim('A', 2),
+		MinLength(5)) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		got = append(got, string(pair[0].Sequence)+","+
+			string(pair[1].Sequence))
+	}
+	want := []string{"ACGTAC,ACGTAC"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ApplyPaired(...)=%v, want %v", got, want)
+	}
+	if gotErr == nil {
+		t.Fatalf("ApplyPaired(...) succeeded, want error")
+	}
+}
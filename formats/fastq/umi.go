@@ -0,0 +1,188 @@
+// UMI extraction and deduplication.
+
+package fastq
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// A UMIPattern describes the barcode positions at the start of reads, using
+// the syntax of umi_tools. Each character describes a single base: N for a
+// UMI base, C for a cell barcode base, and X for a base that stays in the read.
+// For example, "CCCCCCCCNNNNNNNN" is an 8-base cell barcode followed by an
+// 8-base UMI.
+type UMIPattern string
+
+// ParseUMIPattern returns the pattern in s, after checking that it is valid.
+// A valid pattern has only N, C and X characters, and at least one N.
+func ParseUMIPattern(s string) (UMIPattern, error) {
+	for i, c := range []byte(s) {
+		if c != 'N' && c != 'C' && c != 'X' {
+			return "", fmt.Errorf("bad character at position %v of UMI "+
+				"pattern: %q, want N, C or X", i, c)
+		}
+	}
+	if !bytes.ContainsRune([]byte(s), 'N') {
+		return "", fmt.Errorf("UMI pattern %q has no UMI bases", s)
+	}
+	return UMIPattern(s), nil
+}
+
+// Barcodes are the bases and qualities that were extracted from a read.
+type Barcodes struct {
+	Cell      []byte // Cell barcode bases
+	CellQuals []byte // Cell barcode qualities
+	UMI       []byte // UMI bases
+	UMIQuals  []byte // UMI qualities
+}
+
+// Extract removes the barcode bases of the pattern from the start of fq, along
+// with their qualities, and returns them. Returns false and leaves fq
+// unchanged if fq is shorter than the pattern.
+func (p UMIPattern) Extract(fq *Fastq) (Barcodes, bool) {
+	if len(fq.Sequence) < len(p) || len(fq.Quals) < len(p) {
+		return Barcodes{}, false
+	}
+	var b Barcodes
+	var seq, quals []byte
+	for i, c := range []byte(p) {
+		switch c {
+		case 'N':
+			b.UMI = append(b.UMI, fq.Sequence[i])
+			b.UMIQuals = append(b.UMIQuals, fq.Quals[i])
+		case 'C':
+			b.Cell = append(b.Cell, fq.Sequence[i])
+			b.CellQuals = append(b.CellQuals, fq.Quals[i])
+		default:
+			seq = append(seq, fq.Sequence[i])
+			quals = append(quals, fq.Quals[i])
+		}
+	}
+	fq.Sequence = append(seq, fq.Sequence[len(p):]...)
+	fq.Quals = append(quals, fq.Quals[len(p):]...)
+	return b, true
+}
+
+// ExtractUMI returns a transform that extracts barcodes from each read using
+// p, and adds them to the read's name as in umi_tools. The cell barcode and
+// UMI are added to the name's ID (before the first whitespace), as
+// "ID_CELL_UMI", or "ID_UMI" if p has no cell barcode. If quals is true, their
+// qualities are added at the end of the name as SAM-style tags, as in
+// "ID_CELL_UMI comment CY:Z:QUALS UY:Z:QUALS". Reads that are shorter than the
+// pattern are dropped.
+func ExtractUMI(p UMIPattern, quals bool) Transform {
+	hasCell := bytes.ContainsRune([]byte(p), 'C')
+	return func(fq *Fastq) bool {
+		b, ok := p.Extract(fq)
+		if !ok {
+			return false
+		}
+		id, rest := splitName(fq.Name)
+		name := slices.Clip(id)
+		if hasCell {
+			name = append(append(name, '_'), b.Cell...)
+		}
+		name = append(append(name, '_'), b.UMI...)
+		name = append(name, rest...)
+		if quals {
+			if hasCell {
+				name = append(append(name, " CY:Z:"...), b.CellQuals...)
+			}
+			name = append(append(name, " UY:Z:"...), b.UMIQuals...)
+		}
+		fq.Name = name
+		return true
+	}
+}
+
+// Splits a read name to its ID and the rest, which is empty or starts with
+// whitespace.
+func splitName(name []byte) (id, rest []byte) {
+	i := bytes.IndexAny(name, " \t")
+	if i == -1 {
+		return name, nil
+	}
+	return name[:i], name[i:]
+}
+
+// Returns the cell barcode and UMI in a read name, as added by ExtractUMI.
+// Returns false if the name has no barcodes.
+func nameBarcodes(name []byte, hasCell bool) (cell, umi []byte, ok bool) {
+	id, _ := splitName(name)
+	i := bytes.LastIndexByte(id, '_')
+	if i == -1 {
+		return nil, nil, false
+	}
+	umi = id[i+1:]
+	if !hasCell {
+		return nil, umi, true
+	}
+	j := bytes.LastIndexByte(id[:i], '_')
+	if j == -1 {
+		return nil, nil, false
+	}
+	return id[j+1 : i], umi, true
+}
+
+// Dedup returns an iterator over the reads that are not duplicates of earlier
+// reads. A read is a duplicate if an earlier read that was kept has the same
+// sequence and cell barcode, and a UMI of the same length that differs in at
+// most maxDist positions. A maxDist of 0 means exact UMI matching.
+//
+// Barcodes are taken from the read names, as added by ExtractUMI. If hasCell
+// is false, names are expected to have only a UMI. Returns an error if a name
+// has no barcodes.
+//
+// Memory usage is proportional to the total length of the reads that are kept.
+func Dedup(reads iter.Seq2[*Fastq, error], maxDist int,
+	hasCell bool) iter.Seq2[*Fastq, error] {
+	return func(yield func(*Fastq, error) bool) {
+		seen := map[string][][]byte{} // UMIs by cell and sequence.
+		var key []byte
+		for fq, err := range reads {
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+			cell, umi, ok := nameBarcodes(fq.Name, hasCell)
+			if !ok {
+				yield(nil, fmt.Errorf("read %q has no barcodes", fq.Name))
+				return
+			}
+			key = append(append(append(key[:0], cell...), ' '),
+				fq.Sequence...)
+			umis := seen[string(key)]
+			if slices.ContainsFunc(umis, func(u []byte) bool {
+				return withinHamming(u, umi, maxDist)
+			}) {
+				continue
+			}
+			seen[string(key)] = append(umis, slices.Clone(umi))
+			if !yield(fq, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Returns whether a and b have the same length and differ in at most d
+// positions.
+func withinHamming(a, b []byte, d int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			d--
+			if d < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package fastq
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseUMIPattern(t *testing.T) {
+	for _, s := range []string{"NNNN", "CCCCNNNN", "NNXXCC", "XN"} {
+		if _, err := ParseUMIPattern(s); err != nil {
+			t.Errorf("ParseUMIPattern(%q) failed: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "CCCC", "XXX", "NNNA", "nnnn"} {
+		if _, err := ParseUMIPattern(s); err == nil {
+			t.Errorf("ParseUMIPattern(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestUMIPattern_Extract(t *testing.T) {
+	fq := &Fastq{[]byte("r"), []byte("AACCGTTACGT"), []byte("abcdefghijk")}
+	b, ok := UMIPattern("CCNNXC").Extract(fq)
+	if !ok {
+		t.Fatalf("Extract(...) failed")
+	}
+	want := Barcodes{[]byte("AAT"), []byte("abf"), []byte("CC"), []byte("cd")}
+	if !reflect.DeepEqual(b, want) {
+		t.Fatalf("Extract(...)=%q, want %q", b, want)
+	}
+	if string(fq.Sequence) != "GTACGT" || string(fq.Quals) != "eghijk" {
+		t.Fatalf("Extract(...) left %q,%q, want %q,%q",
+			fq.Sequence, fq.Quals, "GTACGT", "eghijk")
+	}
+
+	short := &Fastq{[]byte("r"), []byte("AAC"), []byte("abc")}
+	if _, ok := UMIPattern("CCNN").Extract(short); ok {
+		t.Fatalf("Extract(%q) succeeded, want fail", short.Sequence)
+	}
+	if string(short.Sequence) != "AAC" {
+		t.Fatalf("Extract(%q) changed the read", short.Sequence)
+	}
+}
+
+func TestExtractUMI(t *testing.T) {
+	tests := []struct {
+		pattern UMIPattern
+		quals   bool
+		name    string
+		want    string
+	}{
+		{"CCNNN", false, "r1", "r1_AC_GTA"},
+		{"CCNNN", false, "r1 1:N:0:A", "r1_AC_GTA 1:N:0:A"},
+		{"NNN", false, "r1", "r1_ACG"},
+		{"XNNN", false, "r1\tx", "r1_CGT\tx"},
+		{"CCNNN", true, "r1 c", "r1_AC_GTA c CY:Z:ab UY:Z:cde"},
+		{"NNN", true, "r1", "r1_ACG UY:Z:abc"},
+	}
+	for _, test := range tests {
+		fq := &Fastq{[]byte(test.name), []byte("ACGTACGT"),
+			[]byte("abcdefgh")}
+		if !ExtractUMI(test.pattern, test.quals)(fq) {
+			t.Fatalf("ExtractUMI(%q,%v)(%q) dropped the read",
+				test.pattern, test.quals, test.name)
+		}
+		if string(fq.Name) != test.want {
+			t.Errorf("ExtractUMI(%q,%v)(%q)=%q, want %q",
+				test.pattern, test.quals, test.name, fq.Name, test.want)
+		}
+	}
+	fq := &Fastq{[]byte("r1"), []byte("ACG"), []byte("abc")}
+	if ExtractUMI("CCNN", false)(fq) {
+		t.Errorf("ExtractUMI(%q)(%q) kept the read, want dropped",
+			"CCNN", fq.Sequence)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	input := "@a_AAAA\nACGT\n+\nIIII\n" +
+		"@b_AAAA\nACGT\n+\nIIII\n" + // Duplicate of a.
+		"@c_AAAT\nACGT\n+\nIIII\n" + // Duplicate of a with 1 mismatch.
+		"@d_AATT\nACGT\n+\nIIII\n" +
+		"@e_AAAA\nACGG\n+\nIIII\n" +
+		"@f_AAA\nACGT\n+\nIIII\n"
+	tests := []struct {
+		maxDist int
+		want    []string
+	}{
+		{0, []string{"a_AAAA", "c_AAAT", "d_AATT", "e_AAAA", "f_AAA"}},
+		{1, []string{"a_AAAA", "d_AATT", "e_AAAA", "f_AAA"}},
+		{2, []string{"a_AAAA", "e_AAAA", "f_AAA"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for fq, err := range Dedup(Reader(strings.NewReader(input)),
+			test.maxDist, false) {
+			if err != nil {
+				t.Fatalf("Dedup(%v) failed: %v", test.maxDist, err)
+			}
+			got = append(got, string(fq.Name))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Dedup(%v)=%q, want %q", test.maxDist, got, test.want)
+		}
+	}
+}
+
+func TestDedup_cell(t *testing.T) {
+	input := "@a_CC_AAAA 1:N:0:A\nACGT\n+\nIIII\n" +
+		"@b_CC_AAAA\nACGT\n+\nIIII\n" +
+		"@c_GG_AAAA\nACGT\n+\nIIII\n"
+	var got []string
+	for fq, err := range Dedup(Reader(strings.NewReader(input)), 0, true) {
+		if err != nil {
+			t.Fatalf("Dedup(...) failed: %v", err)
+		}
+		got = append(got, string(fq.Name))
+	}
+	want := []string{"a_CC_AAAA 1:N:0:A", "c_GG_AAAA"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Dedup(...)=%q, want %q", got, want)
+	}
+
+	for _, err := range Dedup(Reader(strings.NewReader(
+		"@a_AAAA\nACGT\n+\nIIII\n")), 0, true) {
+		if err == nil {
+			t.Errorf("Dedup(...) succeeded, want error")
+		}
+	}
+}
@@ -0,0 +1,784 @@
+// BAM handling.
+
+package sam
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fluhus/biostuff/formats/bgzf"
+)
+
+// Magic bytes at the start of BAM data.
+var bamMagic = []byte("BAM\x01")
+
+// Sequence characters by their 4-bit codes in BAM.
+const bamSeqChars = "=ACMGRSVTWYHKDBN"
+
+// 4-bit codes of sequence characters in BAM. Unknown characters are N.
+var bamSeqCodes [256]byte
+
+func init() {
+	for i := range bamSeqCodes {
+		bamSeqCodes[i] = 15
+	}
+	for i, c := range []byte(bamSeqChars) {
+		bamSeqCodes[c] = byte(i)
+		bamSeqCodes[c|0x20] = byte(i) // Lower case.
+	}
+}
+
+// ReaderBAM reads the header of BAM data from r, and returns it with an
+// iterator over the alignments that follow.
+func ReaderBAM(r io.Reader) (*Header, iter.Seq2[*SAM, error], error) {
+	br := bufio.NewReader(bgzf.NewReader(r))
+	h, refs, _, err := readBAMHeader(br)
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, readBAMRecords(br, refs), nil
+}
+
+// FileBAM reads the header of a BAM file, and returns it with an iterator
+// over the alignments in the file.
+//
+// The file stays open until iteration ends, so the iterator should be used
+// exactly once.
+func FileBAM(file string) (*Header, iter.Seq2[*SAM, error], error) {
+	f, err := bgzf.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	br := bufio.NewReader(f)
+	h, refs, _, err := readBAMHeader(br)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return h, func(yield func(*SAM, error) bool) {
+		defer f.Close()
+		for s, err := range readBAMRecords(br, refs) {
+			if !yield(s, err) {
+				return
+			}
+		}
+	}, nil
+}
+
+// Returns an iterator over the alignments in a BAM file.
+func fileBAM(file string) iter.Seq2[*SAM, error] {
+	return func(yield func(*SAM, error) bool) {
+		f, err := bgzf.Open(file)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer f.Close()
+		br := bufio.NewReader(f)
+		_, refs, _, err := readBAMHeader(br)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for s, err := range readBAMRecords(br, refs) {
+			if !yield(s, err) {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator over the header lines and alignments in a BAM file.
+func fileHeaderBAM(file string) iter.Seq2[SAMOrHeader, error] {
+	return func(yield func(SAMOrHeader, error) bool) {
+		f, err := bgzf.Open(file)
+		if err != nil {
+			yield(SAMOrHeader{}, err)
+			return
+		}
+		defer f.Close()
+		br := bufio.NewReader(f)
+		_, refs, lines, err := readBAMHeader(br)
+		if err != nil {
+			yield(SAMOrHeader{}, err)
+			return
+		}
+		for _, line := range lines {
+			if !yield(SAMOrHeader{H: &line}, nil) {
+				return
+			}
+		}
+		for s, err := range readBAMRecords(br, refs) {
+			if !yield(SAMOrHeader{S: s}, err) {
+				return
+			}
+		}
+	}
+}
+
+// Reads the header of decompressed BAM data. Returns the parsed header, the
+// reference names by their IDs, and the header text lines.
+func readBAMHeader(r io.Reader) (*Header, []string, []string, error) {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, nil, nil, fmt.Errorf("bam: reading magic: %w", err)
+	}
+	if !bytes.Equal(magic, bamMagic) {
+		return nil, nil, nil, fmt.Errorf("bam: bad magic: %q", magic)
+	}
+	text, err := readBAMBytes(r)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("bam: reading header text: %w", err)
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(string(text), "\x00"),
+		"\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	h, err := ParseHeader(lines)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("bam: %w", err)
+	}
+
+	var nref int32
+	if err := binary.Read(r, binary.LittleEndian, &nref); err != nil {
+		return nil, nil, nil, fmt.Errorf("bam: reading references: %w",
+			noEOF(err))
+	}
+	if nref < 0 {
+		return nil, nil, nil, fmt.Errorf("bam: bad number of references: %v",
+			nref)
+	}
+	var refs []string
+	var lengths []int
+	for range nref {
+		name, err := readBAMBytes(r)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("bam: reading references: %w",
+				err)
+		}
+		var n int32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, nil, nil, fmt.Errorf("bam: reading references: %w",
+				noEOF(err))
+		}
+		refs = append(refs, strings.TrimRight(string(name), "\x00"))
+		lengths = append(lengths, int(n))
+	}
+
+	// Use the binary reference list if the text has none.
+	if len(h.References) == 0 {
+		for i, name := range refs {
+			h.References = append(h.References,
+				Reference{name, lengths[i], map[string]string{}})
+		}
+	}
+	return h, refs, lines, nil
+}
+
+// Reads a length-prefixed byte sequence.
+func readBAMBytes(r io.Reader) ([]byte, error) {
+	var n int32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, noEOF(err)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("bad length: %v", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, noEOF(err)
+	}
+	return b, nil
+}
+
+// Converts io.EOF to io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Returns an iterator over the alignment records in decompressed BAM data.
+func readBAMRecords(r io.Reader, refs []string) iter.Seq2[*SAM, error] {
+	return func(yield func(*SAM, error) bool) {
+		var buf []byte
+		for {
+			var size [4]byte
+			if _, err := io.ReadFull(r, size[:]); err != nil {
+				if err != io.EOF {
+					yield(nil, fmt.Errorf("bam: %w", noEOF(err)))
+				}
+				return
+			}
+			n := binary.LittleEndian.Uint32(size[:])
+			if n > math.MaxInt32 {
+				yield(nil, fmt.Errorf("bam: bad record size: %v", n))
+				return
+			}
+			buf = slices.Grow(buf[:0], int(n))[:n]
+			if _, err := io.ReadFull(r, buf); err != nil {
+				yield(nil, fmt.Errorf("bam: %w", noEOF(err)))
+				return
+			}
+			s, err := decodeBAMRecord(buf, refs)
+			if err != nil {
+				yield(nil, fmt.Errorf("bam: %w", err))
+				return
+			}
+			if !yield(s, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Size of the fixed part of a BAM record, without the block size.
+const bamFixedSize = 32
+
+// Decodes a single BAM record, without the block size.
+func decodeBAMRecord(b []byte, refs []string) (*SAM, error) {
+	if len(b) < bamFixedSize {
+		return nil, fmt.Errorf("record too short: %v bytes", len(b))
+	}
+	le := binary.LittleEndian
+	refID := int32(le.Uint32(b))
+	pos := int32(le.Uint32(b[4:]))
+	nameLen := int(b[8])
+	mapq := b[9]
+	ncigar := int(le.Uint16(b[12:]))
+	flag := le.Uint16(b[14:])
+	seqLen := int(int32(le.Uint32(b[16:])))
+	nextRefID := int32(le.Uint32(b[20:]))
+	nextPos := int32(le.Uint32(b[24:]))
+	tlen := int32(le.Uint32(b[28:]))
+	if seqLen < 0 {
+		return nil, fmt.Errorf("bad sequence length: %v", seqLen)
+	}
+	b = b[bamFixedSize:]
+	if len(b) < nameLen+ncigar*4+(seqLen+1)/2+seqLen {
+		return nil, fmt.Errorf("record too short for its fields")
+	}
+
+	rname, err := bamRefName(refID, refs)
+	if err != nil {
+		return nil, err
+	}
+	rnext, err := bamRefName(nextRefID, refs)
+	if err != nil {
+		return nil, err
+	}
+	if nextRefID == refID && refID != -1 {
+		rnext = "="
+	}
+	s := &SAM{
+		Qname: string(bytes.TrimRight(b[:nameLen], "\x00")),
+		Flag:  Flag(flag),
+		Rname: rname,
+		Pos:   int(pos) + 1,
+		Mapq:  int(mapq),
+		Rnext: rnext,
+		Pnext: int(nextPos) + 1,
+		Tlen:  int(tlen),
+	}
+	b = b[nameLen:]
+
+	cigar := make(Cigar, ncigar)
+	for i := range cigar {
+		x := le.Uint32(b[i*4:])
+		if x&0xf >= uint32(len(cigarOps)) {
+			return nil, fmt.Errorf("bad cigar operation: %v", x&0xf)
+		}
+		cigar[i] = CigarOp{cigarOps[x&0xf], int(x >> 4)}
+	}
+	s.Cigar = cigar.String()
+	b = b[ncigar*4:]
+
+	if seqLen == 0 {
+		s.Seq, s.Qual = "*", "*"
+	} else {
+		seq := make([]byte, seqLen)
+		for i := range seq {
+			code := b[i/2] >> 4
+			if i%2 == 1 {
+				code = b[i/2] & 0xf
+			}
+			seq[i] = bamSeqChars[code]
+		}
+		s.Seq = string(seq)
+		b = b[(seqLen+1)/2:]
+		if b[0] == 0xff {
+			s.Qual = "*"
+		} else {
+			qual := make([]byte, seqLen)
+			for i := range qual {
+				qual[i] = b[i] + 33
+			}
+			s.Qual = string(qual)
+		}
+		b = b[seqLen:]
+	}
+
+	s.Tags, err = decodeBAMTags(b)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Returns the name of the reference with the given ID.
+func bamRefName(id int32, refs []string) (string, error) {
+	if id == -1 {
+		return "*", nil
+	}
+	if id < 0 || int(id) >= len(refs) {
+		return "", fmt.Errorf("bad reference ID: %v", id)
+	}
+	return refs[id], nil
+}
+
+// Decodes the tags of a BAM record.
+func decodeBAMTags(b []byte) (map[string]any, error) {
+	le := binary.LittleEndian
+	tags := map[string]any{}
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, fmt.Errorf("truncated tag")
+		}
+		name, typ := string(b[:2]), b[2]
+		b = b[3:]
+		if typ == 'Z' || typ == 'H' {
+			i := bytes.IndexByte(b, 0)
+			if i == -1 {
+				return nil, fmt.Errorf("unterminated tag %v", name)
+			}
+			if typ == 'Z' {
+				tags[name] = string(b[:i])
+			} else {
+				x, err := hex.DecodeString(string(b[:i]))
+				if err != nil {
+					return nil, fmt.Errorf("bad hex value for tag %v: %q",
+						name, b[:i])
+				}
+				tags[name] = x
+			}
+			b = b[i+1:]
+			continue
+		}
+		if typ == 'B' {
+			if len(b) < 5 {
+				return nil, fmt.Errorf("truncated tag %v", name)
+			}
+			sub, n := b[0], int(le.Uint32(b[1:]))
+			b = b[5:]
+			size := bamTagSize(sub)
+			if size == 0 || sub == 'A' {
+				return nil, fmt.Errorf("bad array type for tag %v: %q",
+					name, sub)
+			}
+			if n < 0 || len(b) < n*size {
+				return nil, fmt.Errorf("truncated tag %v", name)
+			}
+			tags[name] = decodeBAMArray(sub, n, b)
+			b = b[n*size:]
+			continue
+		}
+		size := bamTagSize(typ)
+		if size == 0 {
+			return nil, fmt.Errorf("bad type for tag %v: %q", name, typ)
+		}
+		if len(b) < size {
+			return nil, fmt.Errorf("truncated tag %v", name)
+		}
+		tags[name] = decodeBAMValue(typ, b)
+		b = b[size:]
+	}
+	return tags, nil
+}
+
+// Returns the number of bytes of a single value of the given BAM tag type, or
+// 0 if the type does not have a fixed size.
+func bamTagSize(typ byte) int {
+	switch typ {
+	case 'A', 'c', 'C':
+		return 1
+	case 's', 'S':
+		return 2
+	case 'i', 'I', 'f':
+		return 4
+	default:
+		return 0
+	}
+}
+
+// Decodes a single value of a fixed-size BAM tag type.
+func decodeBAMValue(typ byte, b []byte) any {
+	le := binary.LittleEndian
+	switch typ {
+	case 'A':
+		return b[0]
+	case 'c':
+		return int(int8(b[0]))
+	case 'C':
+		return int(b[0])
+	case 's':
+		return int(int16(le.Uint16(b)))
+	case 'S':
+		return int(le.Uint16(b))
+	case 'i':
+		return int(int32(le.Uint32(b)))
+	case 'I':
+		return int(le.Uint32(b))
+	case 'f':
+		return float32To64(math.Float32frombits(le.Uint32(b)))
+	default:
+		panic(fmt.Sprintf("unsupported type: %q", typ))
+	}
+}
+
+// Converts x to the float64 with the same shortest decimal representation, so
+// that 0.1 stays 0.1 rather than 0.10000000149011612.
+func float32To64(x float32) float64 {
+	y, _ := strconv.ParseFloat(strconv.FormatFloat(float64(x), 'g', -1, 32),
+		64)
+	return y
+}
+
+// Decodes a B-array tag value of n elements of the given type.
+func decodeBAMArray(typ byte, n int, b []byte) any {
+	le := binary.LittleEndian
+	switch typ {
+	case 'c':
+		a := make([]int8, n)
+		for i := range a {
+			a[i] = int8(b[i])
+		}
+		return a
+	case 'C':
+		return Uint8Array(slices.Clone(b[:n]))
+	case 's':
+		a := make([]int16, n)
+		for i := range a {
+			a[i] = int16(le.Uint16(b[i*2:]))
+		}
+		return a
+	case 'S':
+		a := make([]uint16, n)
+		for i := range a {
+			a[i] = le.Uint16(b[i*2:])
+		}
+		return a
+	case 'i':
+		a := make([]int32, n)
+		for i := range a {
+			a[i] = int32(le.Uint32(b[i*4:]))
+		}
+		return a
+	case 'I':
+		a := make([]uint32, n)
+		for i := range a {
+			a[i] = le.Uint32(b[i*4:])
+		}
+		return a
+	case 'f':
+		a := make([]float32, n)
+		for i := range a {
+			a[i] = math.Float32frombits(le.Uint32(b[i*4:]))
+		}
+		return a
+	default:
+		panic(fmt.Sprintf("unsupported type: %q", typ))
+	}
+}
+
+// A BAMWriter writes alignments in BAM format.
+type BAMWriter struct {
+	w    *bgzf.Writer
+	refs map[string]int32 // Reference IDs by name
+	buf  []byte
+}
+
+// NewBAMWriter returns a writer that writes BAM data to w, and writes the
+// header. Alignments may refer only to the references in the header. Close
+// should be called at the end.
+func NewBAMWriter(w io.Writer, h *Header) (*BAMWriter, error) {
+	return newBAMWriter(bgzf.NewWriter(w), h)
+}
+
+// CreateBAM creates a BAM file and writes the header. Alignments may refer
+// only to the references in the header. Close should be called at the end,
+// and closes the file as well.
+func CreateBAM(file string, h *Header) (*BAMWriter, error) {
+	bw, err := bgzf.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	w, err := newBAMWriter(bw, h)
+	if err != nil {
+		bw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Returns a BAM writer that writes to bw, after writing the header.
+func newBAMWriter(bw *bgzf.Writer, h *Header) (*BAMWriter, error) {
+	text, err := h.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	le := binary.LittleEndian
+	buf := slices.Clone(bamMagic)
+	buf = le.AppendUint32(buf, uint32(len(text)))
+	buf = append(buf, text...)
+	buf = le.AppendUint32(buf, uint32(len(h.References)))
+	refs := make(map[string]int32, len(h.References))
+	for i, ref := range h.References {
+		buf = le.AppendUint32(buf, uint32(len(ref.Name)+1))
+		buf = append(buf, ref.Name...)
+		buf = append(buf, 0)
+		buf = le.AppendUint32(buf, uint32(ref.Length))
+		refs[ref.Name] = int32(i)
+	}
+	if _, err := bw.Write(buf); err != nil {
+		return nil, err
+	}
+	return &BAMWriter{w: bw, refs: refs}, nil
+}
+
+// Write writes a single alignment.
+func (w *BAMWriter) Write(s *SAM) error {
+	var err error
+	w.buf, err = w.encode(w.buf[:0], s)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(w.buf)
+	return err
+}
+
+// Close writes the remaining data and the end-of-file marker. Does not close
+// the underlying writer, unless the writer was created with CreateBAM.
+func (w *BAMWriter) Close() error {
+	return w.w.Close()
+}
+
+// Returns the ID of the given reference name.
+func (w *BAMWriter) refID(name string) (int32, error) {
+	if name == "*" {
+		return -1, nil
+	}
+	id, ok := w.refs[name]
+	if !ok {
+		return 0, fmt.Errorf("reference %q is not in the header", name)
+	}
+	return id, nil
+}
+
+// Appends the BAM record of s to buf, including the block size.
+func (w *BAMWriter) encode(buf []byte, s *SAM) ([]byte, error) {
+	le := binary.LittleEndian
+	refID, err := w.refID(s.Rname)
+	if err != nil {
+		return nil, err
+	}
+	nextRefID := refID
+	if s.Rnext != "=" {
+		nextRefID, err = w.refID(s.Rnext)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(s.Qname) > 254 {
+		return nil, fmt.Errorf("query name too long: %v, max 254",
+			len(s.Qname))
+	}
+	if s.Mapq < 0 || s.Mapq > 255 {
+		return nil, fmt.Errorf("bad mapping quality: %v", s.Mapq)
+	}
+	if s.Flag < 0 || s.Flag > 0xffff {
+		return nil, fmt.Errorf("bad flag: %v", s.Flag)
+	}
+	cigar, err := ParseCigar(s.Cigar)
+	if err != nil {
+		return nil, err
+	}
+	if len(cigar) > 0xffff {
+		return nil, fmt.Errorf("too many cigar operations: %v", len(cigar))
+	}
+	seq := s.Seq
+	if seq == "*" {
+		seq = ""
+	}
+	if s.Qual != "*" && len(s.Qual) != len(seq) {
+		return nil, fmt.Errorf("sequence and qualities have different "+
+			"lengths: %v and %v", len(seq), len(s.Qual))
+	}
+
+	pos := s.Pos - 1
+	end := pos + max(cigar.RefLen(), 1)
+	buf = le.AppendUint32(buf, 0) // Block size, set at the end.
+	buf = le.AppendUint32(buf, uint32(refID))
+	buf = le.AppendUint32(buf, uint32(int32(pos)))
+	buf = append(buf, byte(len(s.Qname)+1), byte(s.Mapq))
+	buf = le.AppendUint16(buf, uint16(reg2bin(pos, end)))
+	buf = le.AppendUint16(buf, uint16(len(cigar)))
+	buf = le.AppendUint16(buf, uint16(s.Flag))
+	buf = le.AppendUint32(buf, uint32(len(seq)))
+	buf = le.AppendUint32(buf, uint32(nextRefID))
+	buf = le.AppendUint32(buf, uint32(int32(s.Pnext-1)))
+	buf = le.AppendUint32(buf, uint32(int32(s.Tlen)))
+	buf = append(buf, s.Qname...)
+	buf = append(buf, 0)
+	for _, op := range cigar {
+		code := strings.IndexByte(cigarOps, op.Op)
+		buf = le.AppendUint32(buf, uint32(op.Len)<<4|uint32(code))
+	}
+	for i := 0; i < len(seq); i += 2 {
+		b := bamSeqCodes[seq[i]] << 4
+		if i+1 < len(seq) {
+			b |= bamSeqCodes[seq[i+1]]
+		}
+		buf = append(buf, b)
+	}
+	if s.Qual == "*" {
+		for range seq {
+			buf = append(buf, 0xff)
+		}
+	} else {
+		for i := range len(s.Qual) {
+			if s.Qual[i] < 33 {
+				return nil, fmt.Errorf("bad quality character: %q", s.Qual[i])
+			}
+			buf = append(buf, s.Qual[i]-33)
+		}
+	}
+
+	keys := make([]string, 0, len(s.Tags))
+	for k := range s.Tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if len(k) != 2 {
+			return nil, fmt.Errorf("tag identifier %q should be 2-char long", k)
+		}
+		buf, err = appendBAMTag(buf, k, s.Tags[k])
+		if err != nil {
+			return nil, err
+		}
+	}
+	le.PutUint32(buf, uint32(len(buf)-4))
+	return buf, nil
+}
+
+// Appends a single tag in BAM format to buf.
+func appendBAMTag(buf []byte, name string, val any) ([]byte, error) {
+	le := binary.LittleEndian
+	buf = append(buf, name...)
+	switch val := val.(type) {
+	case byte:
+		return append(buf, 'A', val), nil
+	case int:
+		switch {
+		case val >= 0 && val <= math.MaxUint8:
+			return append(buf, 'C', byte(val)), nil
+		case val >= math.MinInt8 && val < 0:
+			return append(buf, 'c', byte(val)), nil
+		case val >= 0 && val <= math.MaxUint16:
+			return le.AppendUint16(append(buf, 'S'), uint16(val)), nil
+		case val >= math.MinInt16 && val < 0:
+			return le.AppendUint16(append(buf, 's'), uint16(val)), nil
+		case val >= 0 && val <= math.MaxUint32:
+			return le.AppendUint32(append(buf, 'I'), uint32(val)), nil
+		case val >= math.MinInt32 && val < 0:
+			return le.AppendUint32(append(buf, 'i'), uint32(val)), nil
+		default:
+			return nil, fmt.Errorf("value of tag %v out of range: %v",
+				name, val)
+		}
+	case float64:
+		return le.AppendUint32(append(buf, 'f'),
+			math.Float32bits(float32(val))), nil
+	case string:
+		return append(append(append(buf, 'Z'), val...), 0), nil
+	case []byte:
+		return append(hex.AppendEncode(append(buf, 'H'), val), 0), nil
+	case []int8:
+		buf = appendBAMArrayHeader(buf, 'c', len(val))
+		for _, x := range val {
+			buf = append(buf, byte(x))
+		}
+		return buf, nil
+	case Uint8Array:
+		return append(appendBAMArrayHeader(buf, 'C', len(val)), val...), nil
+	case []int16:
+		buf = appendBAMArrayHeader(buf, 's', len(val))
+		for _, x := range val {
+			buf = le.AppendUint16(buf, uint16(x))
+		}
+		return buf, nil
+	case []uint16:
+		buf = appendBAMArrayHeader(buf, 'S', len(val))
+		for _, x := range val {
+			buf = le.AppendUint16(buf, x)
+		}
+		return buf, nil
+	case []int32:
+		buf = appendBAMArrayHeader(buf, 'i', len(val))
+		for _, x := range val {
+			buf = le.AppendUint32(buf, uint32(x))
+		}
+		return buf, nil
+	case []uint32:
+		buf = appendBAMArrayHeader(buf, 'I', len(val))
+		for _, x := range val {
+			buf = le.AppendUint32(buf, x)
+		}
+		return buf, nil
+	case []float32:
+		buf = appendBAMArrayHeader(buf, 'f', len(val))
+		for _, x := range val {
+			buf = le.AppendUint32(buf, math.Float32bits(x))
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("unsupported type for tag %v: %T", name, val)
+	}
+}
+
+// Appends the type, subtype and length of a B-array tag.
+func appendBAMArrayHeader(buf []byte, typ byte, n int) []byte {
+	return binary.LittleEndian.AppendUint32(append(buf, 'B', typ), uint32(n))
+}
+
+// Returns the BAI bin of the 0-based half-open range [beg,end), as in the SAM
+// specification.
+func reg2bin(beg, end int) int {
+	end--
+	switch {
+	case beg>>14 == end>>14:
+		return ((1<<15)-1)/7 + (beg >> 14)
+	case beg>>17 == end>>17:
+		return ((1<<12)-1)/7 + (beg >> 17)
+	case beg>>20 == end>>20:
+		return ((1<<9)-1)/7 + (beg >> 20)
+	case beg>>23 == end>>23:
+		return ((1<<6)-1)/7 + (beg >> 23)
+	case beg>>26 == end>>26:
+		return ((1<<3)-1)/7 + (beg >> 26)
+	}
+	return 0
+}
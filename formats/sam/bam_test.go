@@ -0,0 +1,249 @@
+package sam
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fluhus/biostuff/formats/bgzf"
+)
+
+func TestBAM(t *testing.T) {
+	input := "@HD\tVN:1.6\tSO:coordinate\n" +
+		"@SQ\tSN:chr1\tLN:1000\n" +
+		"@SQ\tSN:chr2\tLN:500\n" +
+		"@RG\tID:rg1\tSM:s1\n" +
+		"r1\t99\tchr1\t10\t60\t2S4M1I3M\t=\t50\t45\tACGTNACGTA\tIIII#IIIII\t" +
+		"AS:i:123\tXN:i:-5\tXS:i:-40000\tXL:i:70000\tXU:i:4000000000\t" +
+		"ZF:f:3.1415\tZA:A:x\tBC:Z:barcode\tZH:H:1234abcd\t" +
+		"ZB:B:c,1,-2\tZC:B:C,3,250\tZS:B:s,-300\tZT:B:S,300\t" +
+		"ZI:B:i,-70000\tZJ:B:I,70000\tZG:B:f,1.5,-2\n" +
+		"r2\t0\tchr2\t1\t0\t*\tchr1\t10\t0\tACG\t*\n" +
+		"r3\t4\t*\t0\t0\t*\t*\t0\t0\t*\t*\n"
+	h, sams, err := ReaderWithHeader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReaderWithHeader(%q) failed: %v", input, err)
+	}
+	var want []*SAM
+	for s, err := range sams {
+		if err != nil {
+			t.Fatalf("ReaderWithHeader(%q) failed: %v", input, err)
+		}
+		want = append(want, s)
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewBAMWriter(buf, h)
+	if err != nil {
+		t.Fatalf("NewBAMWriter() failed: %v", err)
+	}
+	for _, s := range want {
+		if err := w.Write(s); err != nil {
+			t.Fatalf("Write(%v) failed: %v", s, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	gotH, gotSams, err := ReaderBAM(buf)
+	if err != nil {
+		t.Fatalf("ReaderBAM() failed: %v", err)
+	}
+	if !reflect.DeepEqual(gotH, h) {
+		t.Fatalf("ReaderBAM() header=%+v, want %+v", gotH, h)
+	}
+	var got []*SAM
+	for s, err := range gotSams {
+		if err != nil {
+			t.Fatalf("ReaderBAM() failed: %v", err)
+		}
+		got = append(got, s)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReaderBAM()=%v, want %v", got, want)
+	}
+}
+
+func TestBAM_record(t *testing.T) {
+	h := &Header{References: []Reference{{Name: "chr1", Length: 100}}}
+	s := &SAM{"r1", 0, "chr1", 10, 60, "4M", "*", 0, 0, "ACGT", "IIII",
+		map[string]any{"NM": 1}}
+	buf := &bytes.Buffer{}
+	w, err := NewBAMWriter(buf, h)
+	if err != nil {
+		t.Fatalf("NewBAMWriter() failed: %v", err)
+	}
+	if err := w.Write(s); err != nil {
+		t.Fatalf("Write(%v) failed: %v", s, err)
+	}
+	w.Close()
+	data, err := io.ReadAll(bgzf.NewReader(buf))
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+
+	// Magic, header text, one reference and one record.
+	text := "@SQ\tSN:chr1\tLN:100\n"
+	want := []byte("BAM\x01")
+	want = binary.LittleEndian.AppendUint32(want, uint32(len(text)))
+	want = append(want, text...)
+	want = append(want, 1, 0, 0, 0, 5, 0, 0, 0, 'c', 'h', 'r', '1', 0,
+		100, 0, 0, 0)
+	want = append(want,
+		49, 0, 0, 0, // Block size
+		0, 0, 0, 0, // Reference ID
+		9, 0, 0, 0, // Position
+		3, 60, 0x49, 0x12, // Name length, mapq, bin
+		1, 0, 0, 0, // Cigar length, flag
+		4, 0, 0, 0, // Sequence length
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, // Next reference
+		0, 0, 0, 0, // Template length
+		'r', '1', 0,
+		64, 0, 0, 0, // Cigar
+		0x12, 0x48, // Sequence
+		40, 40, 40, 40, // Qualities
+		'N', 'M', 'C', 1,
+	)
+	if !bytes.Equal(data, want) {
+		t.Fatalf("BAM data=%v, want %v", data, want)
+	}
+}
+
+func TestBAM_writeBad(t *testing.T) {
+	h := &Header{References: []Reference{{Name: "chr1", Length: 100}}}
+	good := SAM{"r1", 0, "chr1", 10, 60, "4M", "*", 0, 0, "ACGT", "IIII",
+		map[string]any{}}
+	bad := []func(s *SAM){
+		func(s *SAM) { s.Rname = "chr2" },
+		func(s *SAM) { s.Rnext = "chr2" },
+		func(s *SAM) { s.Qname = strings.Repeat("a", 255) },
+		func(s *SAM) { s.Mapq = 256 },
+		func(s *SAM) { s.Cigar = "4Q" },
+		func(s *SAM) { s.Qual = "III" },
+		func(s *SAM) { s.Tags["ABC"] = 1 },
+		func(s *SAM) { s.Tags["XX"] = 1 << 40 },
+		func(s *SAM) { s.Tags["XX"] = []int{1} },
+	}
+	for _, f := range bad {
+		s := good
+		s.Tags = map[string]any{}
+		f(&s)
+		w, err := NewBAMWriter(io.Discard, h)
+		if err != nil {
+			t.Fatalf("NewBAMWriter() failed: %v", err)
+		}
+		if err := w.Write(&s); err == nil {
+			t.Errorf("Write(%v) succeeded, want error", s)
+		}
+	}
+}
+
+func TestReaderBAM_bad(t *testing.T) {
+	h := &Header{References: []Reference{{Name: "chr1", Length: 100}}}
+	buf := &bytes.Buffer{}
+	w, _ := NewBAMWriter(buf, h)
+	w.Write(&SAM{"r1", 0, "chr1", 10, 60, "4M", "*", 0, 0, "ACGT", "IIII",
+		map[string]any{"ZZ": "abc"}})
+	w.Close()
+	data, _ := io.ReadAll(bgzf.NewReader(buf))
+	badRef := bytes.Clone(data)
+	badRef[len(badRef)-52] = 5 // Reference ID of the record.
+
+	inputs := map[string][]byte{
+		"magic":     append([]byte("BAM\x02"), data[4:]...),
+		"header":    data[:10],
+		"record":    data[:len(data)-10],
+		"tag":       data[:len(data)-1],
+		"reference": badRef,
+	}
+	for name, input := range inputs {
+		var z bytes.Buffer
+		bw := bgzf.NewWriter(&z)
+		bw.Write(input)
+		bw.Close()
+		_, sams, err := ReaderBAM(&z)
+		if err != nil {
+			continue
+		}
+		failed := false
+		for _, err := range sams {
+			if err != nil {
+				failed = true
+			}
+		}
+		if !failed {
+			t.Errorf("ReaderBAM(%s) succeeded, want error", name)
+		}
+	}
+}
+
+func TestFileBAM(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.bam")
+	h := &Header{References: []Reference{
+		{Name: "chr1", Length: 100, Tags: map[string]string{}}}}
+	want := []*SAM{
+		{"r1", 0, "chr1", 10, 60, "4M", "*", 0, 0, "ACGT", "IIII",
+			map[string]any{"AS": 4}},
+		{"r2", 16, "chr1", 20, 30, "2M", "*", 0, 0, "AC", "*",
+			map[string]any{}},
+	}
+	w, err := CreateBAM(file, h)
+	if err != nil {
+		t.Fatalf("CreateBAM(%q) failed: %v", file, err)
+	}
+	for _, s := range want {
+		if err := w.Write(s); err != nil {
+			t.Fatalf("Write(%v) failed: %v", s, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	gotH, sams, err := FileWithHeader(file)
+	if err != nil {
+		t.Fatalf("FileWithHeader(%q) failed: %v", file, err)
+	}
+	if !reflect.DeepEqual(gotH, h) {
+		t.Fatalf("FileWithHeader(%q) header=%+v, want %+v", file, gotH, h)
+	}
+	// The alignments should be read from the already open file.
+	tmp := file + ".tmp"
+	if err := os.Rename(file, tmp); err != nil {
+		t.Fatal(err)
+	}
+	var got []*SAM
+	for s, err := range sams {
+		if err != nil {
+			t.Fatalf("FileWithHeader(%q) failed: %v", file, err)
+		}
+		got = append(got, s)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FileWithHeader(%q)=%v, want %v", file, got, want)
+	}
+
+	if err := os.Rename(tmp, file); err != nil {
+		t.Fatal(err)
+	}
+
+	var headers []string
+	for sh, err := range FileHeader(file) {
+		if err != nil {
+			t.Fatalf("FileHeader(%q) failed: %v", file, err)
+		}
+		if sh.H != nil {
+			headers = append(headers, *sh.H)
+		}
+	}
+	if want := []string{"@SQ\tSN:chr1\tLN:100"}; !reflect.DeepEqual(
+		headers, want) {
+		t.Fatalf("FileHeader(%q) headers=%q, want %q", file, headers, want)
+	}
+}
@@ -0,0 +1,194 @@
+// CIGAR string handling.
+
+package sam
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fluhus/biostuff/align"
+)
+
+// Valid CIGAR operations.
+const cigarOps = "MIDNSHP=X"
+
+// A CigarOp is a single operation in a CIGAR string, such as 10M.
+type CigarOp struct {
+	Op  byte // One of "MIDNSHP=X".
+	Len int  // Number of repetitions, at least 1.
+}
+
+// Cigar is a parsed CIGAR string.
+// The reference is the sequence that the query (SEQ) is aligned to.
+type Cigar []CigarOp
+
+// ParseCigar parses and validates a CIGAR string, such as the Cigar field of
+// a SAM entry. Returns nil for "*" (unavailable).
+func ParseCigar(s string) (Cigar, error) {
+	if s == "*" {
+		return nil, nil
+	}
+	if s == "" {
+		return nil, fmt.Errorf("empty CIGAR string")
+	}
+	var c Cigar
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			continue
+		}
+		if !strings.ContainsRune(cigarOps, rune(s[i])) {
+			return nil, fmt.Errorf("bad operation in CIGAR %q: %q", s, s[i])
+		}
+		if start == i {
+			return nil, fmt.Errorf("missing length in CIGAR %q at position %v",
+				s, i)
+		}
+		n, err := strconv.Atoi(s[start:i])
+		if err != nil {
+			return nil, fmt.Errorf("bad length in CIGAR %q: %v", s, err)
+		}
+		c = append(c, CigarOp{s[i], n})
+		start = i + 1
+	}
+	if start != len(s) {
+		return nil, fmt.Errorf("missing operation at the end of CIGAR %q", s)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks that the CIGAR is valid according to the SAM specification.
+// Operations should be valid and have positive lengths. H may appear only at the
+// ends, and S may appear only at the ends or next to H at the ends.
+func (c Cigar) Validate() error {
+	for i, op := range c {
+		if !strings.ContainsRune(cigarOps, rune(op.Op)) {
+			return fmt.Errorf("bad operation at position %v: %q", i, op.Op)
+		}
+		if op.Len < 1 {
+			return fmt.Errorf("bad length at position %v: %v, want at least 1",
+				i, op.Len)
+		}
+	}
+
+	// Strip clipping and check that none remains in the middle.
+	inner := c
+	if len(inner) > 0 && inner[0].Op == 'H' {
+		inner = inner[1:]
+	}
+	if len(inner) > 0 && inner[len(inner)-1].Op == 'H' {
+		inner = inner[:len(inner)-1]
+	}
+	if len(inner) > 0 && inner[0].Op == 'S' {
+		inner = inner[1:]
+	}
+	if len(inner) > 0 && inner[len(inner)-1].Op == 'S' {
+		inner = inner[:len(inner)-1]
+	}
+	for _, op := range inner {
+		if op.Op == 'H' || op.Op == 'S' {
+			return fmt.Errorf("clipping operation %q in the middle of CIGAR %q",
+				op.Op, c.String())
+		}
+	}
+	return nil
+}
+
+// String returns the textual representation of the CIGAR.
+// Returns "*" for an empty CIGAR.
+func (c Cigar) String() string {
+	if len(c) == 0 {
+		return "*"
+	}
+	buf := &strings.Builder{}
+	for _, op := range c {
+		buf.WriteString(strconv.Itoa(op.Len))
+		buf.WriteByte(op.Op)
+	}
+	return buf.String()
+}
+
+// RefLen returns the number of reference characters that the CIGAR covers.
+// These are the characters in M, D, N, = and X operations.
+func (c Cigar) RefLen() int {
+	n := 0
+	for _, op := range c {
+		switch op.Op {
+		case 'M', 'D', 'N', '=', 'X':
+			n += op.Len
+		}
+	}
+	return n
+}
+
+// QueryLen returns the number of query characters that the CIGAR covers, which
+// should be equal to the length of SEQ. These are the characters in M, I, S, =
+// and X operations.
+func (c Cigar) QueryLen() int {
+	n := 0
+	for _, op := range c {
+		switch op.Op {
+		case 'M', 'I', 'S', '=', 'X':
+			n += op.Len
+		}
+	}
+	return n
+}
+
+// Steps returns the alignment steps that the CIGAR represents, where the
+// reference is the first sequence (a) and the query is the second (b).
+// M, = and X become matches, D and N become deletions, and I becomes
+// insertions. Clipping and padding (S, H, P) are skipped.
+func (c Cigar) Steps() []align.Step {
+	var steps []align.Step
+	for _, op := range c {
+		var step align.Step
+		switch op.Op {
+		case 'M', '=', 'X':
+			step = align.Match
+		case 'D', 'N':
+			step = align.Deletion
+		case 'I':
+			step = align.Insertion
+		default:
+			continue
+		}
+		for range op.Len {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// CigarFromSteps returns the CIGAR representation of the given alignment steps,
+// where the reference is the first sequence (a) and the query is the second
+// (b). Matches become M, deletions become D and insertions become I.
+//
+// To represent a local alignment, add soft clipping (S) of the unaligned parts
+// of the query at the ends.
+func CigarFromSteps(steps []align.Step) Cigar {
+	var c Cigar
+	for _, step := range steps {
+		var op byte
+		switch step {
+		case align.Match:
+			op = 'M'
+		case align.Deletion:
+			op = 'D'
+		case align.Insertion:
+			op = 'I'
+		default:
+			panic(fmt.Sprintf("unknown step value: %d", step))
+		}
+		if len(c) > 0 && c[len(c)-1].Op == op {
+			c[len(c)-1].Len++
+		} else {
+			c = append(c, CigarOp{op, 1})
+		}
+	}
+	return c
+}
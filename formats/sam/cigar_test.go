@@ -0,0 +1,77 @@
+package sam
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fluhus/biostuff/align"
+)
+
+func TestParseCigar(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     Cigar
+		refLen   int
+		queryLen int
+	}{
+		{"*", nil, 0, 0},
+		{"10M", Cigar{{'M', 10}}, 10, 10},
+		{"3S5M2I4D1N6=7X2S", Cigar{{'S', 3}, {'M', 5}, {'I', 2}, {'D', 4},
+			{'N', 1}, {'=', 6}, {'X', 7}, {'S', 2}}, 23, 25},
+		{"5H2S3M1P2M4H", Cigar{{'H', 5}, {'S', 2}, {'M', 3}, {'P', 1},
+			{'M', 2}, {'H', 4}}, 5, 7},
+	}
+	for _, test := range tests {
+		got, err := ParseCigar(test.input)
+		if err != nil {
+			t.Fatalf("ParseCigar(%q) failed: %v", test.input, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("ParseCigar(%q)=%v, want %v", test.input, got, test.want)
+		}
+		if s := got.String(); s != test.input {
+			t.Errorf("ParseCigar(%q).String()=%q, want %q",
+				test.input, s, test.input)
+		}
+		if n := got.RefLen(); n != test.refLen {
+			t.Errorf("ParseCigar(%q).RefLen()=%v, want %v",
+				test.input, n, test.refLen)
+		}
+		if n := got.QueryLen(); n != test.queryLen {
+			t.Errorf("ParseCigar(%q).QueryLen()=%v, want %v",
+				test.input, n, test.queryLen)
+		}
+	}
+}
+
+func TestParseCigar_bad(t *testing.T) {
+	tests := []string{
+		"", "M", "10", "5M3", "5Q", "0M", "-1M", "3M2H4M", "3M2S4M", "2S3H4M",
+	}
+	for _, test := range tests {
+		if got, err := ParseCigar(test); err == nil {
+			t.Errorf("ParseCigar(%q)=%v, want error", test, got)
+		}
+	}
+}
+
+func TestCigarSteps(t *testing.T) {
+	steps := []align.Step{align.Match, align.Match, align.Deletion,
+		align.Insertion, align.Insertion, align.Match}
+	want := Cigar{{'M', 2}, {'D', 1}, {'I', 2}, {'M', 1}}
+	got := CigarFromSteps(steps)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("CigarFromSteps(%v)=%v, want %v", steps, got, want)
+	}
+	if got := got.Steps(); !reflect.DeepEqual(got, steps) {
+		t.Fatalf("%v.Steps()=%v, want %v", want, got, steps)
+	}
+
+	c := Cigar{{'H', 2}, {'S', 1}, {'=', 1}, {'X', 1}, {'N', 2}, {'P', 1},
+		{'I', 1}}
+	wantSteps := []align.Step{align.Match, align.Match, align.Deletion,
+		align.Deletion, align.Insertion}
+	if got := c.Steps(); !reflect.DeepEqual(got, wantSteps) {
+		t.Fatalf("%v.Steps()=%v, want %v", c, got, wantSteps)
+	}
+}
@@ -0,0 +1,394 @@
+// SAM header handling.
+
+package sam
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fluhus/gostuff/aio"
+)
+
+// SortOrder is the sorting order of alignments in a SAM file.
+type SortOrder string
+
+// Possible values of SortOrder.
+const (
+	SortUnknown    SortOrder = "unknown"
+	SortUnsorted   SortOrder = "unsorted"
+	SortQueryName  SortOrder = "queryname"
+	SortCoordinate SortOrder = "coordinate"
+)
+
+// Header is a parsed SAM header. Fields that are not explicitly typed are kept
+// in the Tags maps, by their 2-character keys.
+type Header struct {
+	Version    string            // Format version (@HD VN), empty if no @HD
+	SortOrder  SortOrder         // Sorting order (@HD SO)
+	HDTags     map[string]string // Other @HD fields
+	References []Reference       // Reference sequences (@SQ)
+	ReadGroups []ReadGroup       // Read groups (@RG)
+	Programs   []Program         // Programs (@PG)
+	Comments   []string          // Comments (@CO)
+	Other      []string          // Lines of unrecognized types, with the '@'
+}
+
+// Reference is a reference sequence in a SAM header (@SQ).
+type Reference struct {
+	Name   string            // Reference name (SN)
+	Length int               // Reference length (LN)
+	Tags   map[string]string // Other fields
+}
+
+// ReadGroup is a read group in a SAM header (@RG).
+type ReadGroup struct {
+	ID           string            // Read group ID (ID)
+	Sample       string            // Sample name (SM)
+	Library      string            // Library (LB)
+	Platform     string            // Sequencing platform (PL)
+	PlatformUnit string            // Platform unit, such as lane (PU)
+	Tags         map[string]string // Other fields
+}
+
+// Program is a program in a SAM header (@PG).
+type Program struct {
+	ID          string            // Program ID (ID)
+	Name        string            // Program name (PN)
+	Version     string            // Program version (VN)
+	CommandLine string            // Command line (CL)
+	PreviousID  string            // ID of the previous program (PP)
+	Tags        map[string]string // Other fields
+}
+
+// ParseHeader parses the given header lines, each starting with '@' and
+// without a line break.
+func ParseHeader(lines []string) (*Header, error) {
+	h := &Header{}
+	refs, groups, progs := map[string]bool{}, map[string]bool{},
+		map[string]bool{}
+	for i, line := range lines {
+		if err := h.parseLine(line, refs, groups, progs); err != nil {
+			return nil, fmt.Errorf("header line %v: %w", i+1, err)
+		}
+	}
+	return h, nil
+}
+
+// Parses a single header line into h. The maps hold the names and IDs that
+// were already seen.
+func (h *Header) parseLine(line string, refs, groups,
+	progs map[string]bool) error {
+	if !strings.HasPrefix(line, "@") {
+		return fmt.Errorf("line does not start with '@': %q", line)
+	}
+	typ, rest, _ := strings.Cut(line, "\t")
+	if typ == "@CO" {
+		h.Comments = append(h.Comments, rest)
+		return nil
+	}
+	if typ != "@HD" && typ != "@SQ" && typ != "@RG" && typ != "@PG" {
+		h.Other = append(h.Other, line)
+		return nil
+	}
+
+	tags := map[string]string{}
+	if rest != "" {
+		for _, f := range strings.Split(rest, "\t") {
+			key, val, ok := strings.Cut(f, ":")
+			if !ok || len(key) != 2 {
+				return fmt.Errorf("bad field in %v line: %q", typ, f)
+			}
+			if _, ok := tags[key]; ok {
+				return fmt.Errorf("duplicate field in %v line: %q", typ, key)
+			}
+			tags[key] = val
+		}
+	}
+	pop := func(key string) string {
+		val := tags[key]
+		delete(tags, key)
+		return val
+	}
+
+	switch typ {
+	case "@HD":
+		if h.Version != "" {
+			return fmt.Errorf("more than one @HD line")
+		}
+		h.Version = pop("VN")
+		if h.Version == "" {
+			return fmt.Errorf("@HD line has no VN field")
+		}
+		h.SortOrder = SortOrder(pop("SO"))
+		h.HDTags = tags
+	case "@SQ":
+		ref := Reference{Name: pop("SN"), Tags: tags}
+		if ref.Name == "" {
+			return fmt.Errorf("@SQ line has no SN field")
+		}
+		if refs[ref.Name] {
+			return fmt.Errorf("duplicate reference name: %q", ref.Name)
+		}
+		refs[ref.Name] = true
+		ln, ok := tags["LN"]
+		if !ok {
+			return fmt.Errorf("@SQ line has no LN field")
+		}
+		n, err := strconv.Atoi(pop("LN"))
+		if err != nil || n < 1 {
+			return fmt.Errorf("bad reference length: %q", ln)
+		}
+		ref.Length = n
+		h.References = append(h.References, ref)
+	case "@RG":
+		rg := ReadGroup{ID: pop("ID"), Sample: pop("SM"), Library: pop("LB"),
+			Platform: pop("PL"), PlatformUnit: pop("PU"), Tags: tags}
+		if rg.ID == "" {
+			return fmt.Errorf("@RG line has no ID field")
+		}
+		if groups[rg.ID] {
+			return fmt.Errorf("duplicate read group ID: %q", rg.ID)
+		}
+		groups[rg.ID] = true
+		h.ReadGroups = append(h.ReadGroups, rg)
+	case "@PG":
+		pg := Program{ID: pop("ID"), Name: pop("PN"), Version: pop("VN"),
+			CommandLine: pop("CL"), PreviousID: pop("PP"), Tags: tags}
+		if pg.ID == "" {
+			return fmt.Errorf("@PG line has no ID field")
+		}
+		if progs[pg.ID] {
+			return fmt.Errorf("duplicate program ID: %q", pg.ID)
+		}
+		progs[pg.ID] = true
+		h.Programs = append(h.Programs, pg)
+	}
+	return nil
+}
+
+// ReadHeader reads the header lines at the start of r, and returns the parsed
+// header. Stops before the first line that does not start with '@', so r can
+// be used to read the alignments that follow.
+func ReadHeader(r *bufio.Reader) (*Header, error) {
+	var lines []string
+	for {
+		b, err := r.Peek(1)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != '@' {
+			break
+		}
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		lines = append(lines, strings.TrimRight(line, "\r\n"))
+	}
+	return ParseHeader(lines)
+}
+
+// ReaderWithHeader reads the header of r, and returns it with an iterator over
+// the SAM entries that follow it.
+func ReaderWithHeader(r io.Reader) (*Header, iter.Seq2[*SAM, error], error) {
+	br := bufio.NewReader(r)
+	h, err := ReadHeader(br)
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, Reader(br), nil
+}
+
+// FileWithHeader reads the header of a file, and returns it with an iterator
+// over the SAM entries in the file. Files with a ".bam" suffix are read as BAM.
+//
+// The file stays open until iteration ends, so the iterator should be used
+// exactly once.
+func FileWithHeader(file string) (*Header, iter.Seq2[*SAM, error], error) {
+	if isBAM(file) {
+		return FileBAM(file)
+	}
+	f, err := aio.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	h, err := ReadHeader(&f.Reader)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return h, func(yield func(*SAM, error) bool) {
+		defer f.Close()
+		for s, err := range Reader(&f.Reader) {
+			if !yield(s, err) {
+				break
+			}
+		}
+	}, nil
+}
+
+// MarshalText returns the textual representation of h in SAM format.
+// Includes a trailing new line.
+func (h *Header) MarshalText() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := h.Write(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write writes the header in SAM format to the given writer. Lines are written
+// in the order @HD, @SQ, @RG, @PG, @CO, followed by lines of other types.
+// Typed fields are written first, and other fields follow in sorted order.
+func (h *Header) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if h.Version != "" {
+		writeHeaderLine(bw, "@HD", h.HDTags, "VN", h.Version,
+			"SO", string(h.SortOrder))
+	}
+	for _, ref := range h.References {
+		writeHeaderLine(bw, "@SQ", ref.Tags, "SN", ref.Name,
+			"LN", strconv.Itoa(ref.Length))
+	}
+	for _, rg := range h.ReadGroups {
+		writeHeaderLine(bw, "@RG", rg.Tags, "ID", rg.ID, "SM", rg.Sample,
+			"LB", rg.Library, "PL", rg.Platform, "PU", rg.PlatformUnit)
+	}
+	for _, pg := range h.Programs {
+		writeHeaderLine(bw, "@PG", pg.Tags, "ID", pg.ID, "PN", pg.Name,
+			"VN", pg.Version, "CL", pg.CommandLine, "PP", pg.PreviousID)
+	}
+	for _, c := range h.Comments {
+		fmt.Fprintf(bw, "@CO\t%s\n", c)
+	}
+	for _, line := range h.Other {
+		fmt.Fprintf(bw, "%s\n", line)
+	}
+	return bw.Flush()
+}
+
+// Writes a single header line of the given type. kv are pairs of keys and
+// values of typed fields, which are skipped if empty.
+func writeHeaderLine(w *bufio.Writer, typ string, tags map[string]string,
+	kv ...string) {
+	w.WriteString(typ)
+	for i := 0; i < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			fmt.Fprintf(w, "\t%s:%s", kv[i], kv[i+1])
+		}
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "\t%s:%s", k, tags[k])
+	}
+	w.WriteByte('\n')
+}
+
+// AddProgram appends p to the programs of the header. If p has no PreviousID,
+// it is set to the ID of the last program, so that p continues its chain.
+// Returns an error if a program with the same ID exists.
+func (h *Header) AddProgram(p Program) error {
+	for _, q := range h.Programs {
+		if q.ID == p.ID {
+			return fmt.Errorf("duplicate program ID: %q", p.ID)
+		}
+	}
+	if p.PreviousID == "" && len(h.Programs) > 0 {
+		p.PreviousID = h.Programs[len(h.Programs)-1].ID
+	}
+	h.Programs = append(h.Programs, p)
+	return nil
+}
+
+// ProgramChain returns the chain of programs that ends with the program with
+// the given ID, from the first program to it, following their PreviousID
+// fields. Returns an error if a program in the chain is missing or if the
+// chain has a cycle.
+func (h *Header) ProgramChain(id string) ([]Program, error) {
+	byID := make(map[string]Program, len(h.Programs))
+	for _, p := range h.Programs {
+		byID[p.ID] = p
+	}
+	var chain []Program
+	seen := map[string]bool{}
+	for id != "" {
+		p, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("program %q is not in the header", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("program chain has a cycle at %q", id)
+		}
+		seen[id] = true
+		chain = append(chain, p)
+		id = p.PreviousID
+	}
+	slices.Reverse(chain)
+	return chain, nil
+}
+
+// RefLengths returns the lengths of the reference sequences by their names.
+func (h *Header) RefLengths() map[string]int {
+	m := make(map[string]int, len(h.References))
+	for _, ref := range h.References {
+		m[ref.Name] = ref.Length
+	}
+	return m
+}
+
+// CheckPos checks that the positions of s are within the references, given
+// their lengths as returned by Header.RefLengths. Checks that Rname and Rnext
+// are known references or "*", and that the alignment and Pnext are within
+// their lengths. A Pos or Pnext of 0 (unavailable) is always valid.
+func CheckPos(s *SAM, lengths map[string]int) error {
+	if err := checkRefPos(s.Rname, s.Pos, lengths); err != nil {
+		return err
+	}
+	if s.Pos != 0 && s.Rname != "*" {
+		c, err := ParseCigar(s.Cigar)
+		if err != nil {
+			return err
+		}
+		if end := s.Pos + c.RefLen() - 1; end > lengths[s.Rname] {
+			return fmt.Errorf("alignment end %v is past the end of %q "+
+				"(length %v)", end, s.Rname, lengths[s.Rname])
+		}
+	}
+	rnext := s.Rnext
+	if rnext == "=" {
+		rnext = s.Rname
+	}
+	return checkRefPos(rnext, s.Pnext, lengths)
+}
+
+// Checks that a reference name is known or "*", and that pos is within it.
+func checkRefPos(name string, pos int, lengths map[string]int) error {
+	if pos < 0 {
+		return fmt.Errorf("negative position: %v", pos)
+	}
+	if name == "*" {
+		return nil
+	}
+	n, ok := lengths[name]
+	if !ok {
+		return fmt.Errorf("reference %q is not in the header", name)
+	}
+	if pos > n {
+		return fmt.Errorf("position %v is past the end of %q (length %v)",
+			pos, name, n)
+	}
+	return nil
+}
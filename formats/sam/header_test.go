@@ -0,0 +1,225 @@
+package sam
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fluhus/gostuff/aio"
+)
+
+func TestParseHeader(t *testing.T) {
+	input := "@HD\tVN:1.6\tSO:coordinate\tGO:none\n" +
+		"@SQ\tSN:chr1\tLN:1000\tM5:abc\n" +
+		"@SQ\tSN:chr2\tLN:500\n" +
+		"@RG\tID:rg1\tSM:s1\tPL:ILLUMINA\tDS:hello world\n" +
+		"@PG\tID:bwa\tPN:bwa\tVN:0.7.17\tCL:bwa mem ref.fa r.fq\n" +
+		"@PG\tID:samtools\tPN:samtools\tPP:bwa\n" +
+		"@CO\tsome comment\twith tab\n" +
+		"r1\t0\tchr1\t10\t60\t4M\t*\t0\t0\tACGT\tIIII\n"
+	want := &Header{
+		Version:   "1.6",
+		SortOrder: SortCoordinate,
+		HDTags:    map[string]string{"GO": "none"},
+		References: []Reference{
+			{"chr1", 1000, map[string]string{"M5": "abc"}},
+			{"chr2", 500, map[string]string{}},
+		},
+		ReadGroups: []ReadGroup{{ID: "rg1", Sample: "s1",
+			Platform: "ILLUMINA",
+			Tags:     map[string]string{"DS": "hello world"}}},
+		Programs: []Program{
+			{ID: "bwa", Name: "bwa", Version: "0.7.17",
+				CommandLine: "bwa mem ref.fa r.fq", Tags: map[string]string{}},
+			{ID: "samtools", Name: "samtools", PreviousID: "bwa",
+				Tags: map[string]string{}},
+		},
+		Comments: []string{"some comment\twith tab"},
+	}
+
+	h, sams, err := ReaderWithHeader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReaderWithHeader(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(h, want) {
+		t.Fatalf("ReaderWithHeader(%q)=%+v, want %+v", input, h, want)
+	}
+	var names []string
+	for s, err := range sams {
+		if err != nil {
+			t.Fatalf("ReaderWithHeader(%q) failed: %v", input, err)
+		}
+		names = append(names, s.Qname)
+	}
+	if !reflect.DeepEqual(names, []string{"r1"}) {
+		t.Fatalf("ReaderWithHeader(%q) names=%v, want [r1]", input, names)
+	}
+
+	// Round trip.
+	text, err := h.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() failed: %v", err)
+	}
+	wantText := strings.Join(strings.Split(input, "\n")[:7], "\n") + "\n"
+	if string(text) != wantText {
+		t.Fatalf("MarshalText()=%q, want %q", text, wantText)
+	}
+}
+
+func TestParseHeader_bad(t *testing.T) {
+	inputs := []string{
+		"HD\tVN:1.6",
+		"@HD\tSO:coordinate",
+		"@HD\tVN:1.6\n@HD\tVN:1.6",
+		"@HD\tVN:1.6\tVN:1.5",
+		"@SQ\tLN:100",
+		"@SQ\tSN:chr1",
+		"@SQ\tSN:chr1\tLN:abc",
+		"@SQ\tSN:chr1\tLN:0",
+		"@SQ\tSN:chr1\tLN:10\n@SQ\tSN:chr1\tLN:20",
+		"@RG\tSM:s1",
+		"@RG\tID:a\n@RG\tID:a",
+		"@PG\tPN:bwa",
+		"@PG\tID:a\n@PG\tID:a",
+		"@SQ\tSN:chr1\tLN:10\tbad",
+		"@SQ\tSN:chr1\tLN:10\tABC:x",
+	}
+	for _, input := range inputs {
+		lines := strings.Split(input, "\n")
+		if h, err := ParseHeader(lines); err == nil {
+			t.Errorf("ParseHeader(%q)=%+v, want error", lines, h)
+		}
+	}
+}
+
+func TestReadHeader_empty(t *testing.T) {
+	h, err := ReadHeader(bufio.NewReader(strings.NewReader("")))
+	if err != nil {
+		t.Fatalf("ReadHeader(\"\") failed: %v", err)
+	}
+	if !reflect.DeepEqual(h, &Header{}) {
+		t.Fatalf("ReadHeader(\"\")=%+v, want empty", h)
+	}
+	text, _ := h.MarshalText()
+	if len(text) != 0 {
+		t.Fatalf("MarshalText()=%q, want empty", text)
+	}
+}
+
+func TestHeader_programs(t *testing.T) {
+	h := &Header{}
+	for _, id := range []string{"a", "b", "c"} {
+		if err := h.AddProgram(Program{ID: id}); err != nil {
+			t.Fatalf("AddProgram(%q) failed: %v", id, err)
+		}
+	}
+	if err := h.AddProgram(Program{ID: "d", PreviousID: "a"}); err != nil {
+		t.Fatalf("AddProgram(%q) failed: %v", "d", err)
+	}
+	if err := h.AddProgram(Program{ID: "a"}); err == nil {
+		t.Fatalf("AddProgram(%q) succeeded, want error", "a")
+	}
+
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{"a", []string{"a"}},
+		{"c", []string{"a", "b", "c"}},
+		{"d", []string{"a", "d"}},
+	}
+	for _, test := range tests {
+		chain, err := h.ProgramChain(test.id)
+		if err != nil {
+			t.Fatalf("ProgramChain(%q) failed: %v", test.id, err)
+		}
+		var got []string
+		for _, p := range chain {
+			got = append(got, p.ID)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ProgramChain(%q)=%v, want %v", test.id, got, test.want)
+		}
+	}
+	if _, err := h.ProgramChain("x"); err == nil {
+		t.Errorf("ProgramChain(%q) succeeded, want error", "x")
+	}
+	h.Programs[0].PreviousID = "c"
+	if _, err := h.ProgramChain("c"); err == nil {
+		t.Errorf("ProgramChain(%q) with a cycle succeeded, want error", "c")
+	}
+}
+
+func TestCheckPos(t *testing.T) {
+	h := &Header{References: []Reference{{Name: "chr1", Length: 100},
+		{Name: "chr2", Length: 50}}}
+	lengths := h.RefLengths()
+	good := []*SAM{
+		{Rname: "chr1", Pos: 1, Cigar: "10M", Rnext: "=", Pnext: 91},
+		{Rname: "chr1", Pos: 91, Cigar: "5S10M", Rnext: "chr2", Pnext: 50},
+		{Rname: "*", Pos: 0, Cigar: "*", Rnext: "*", Pnext: 0},
+		{Rname: "chr2", Pos: 0, Cigar: "*", Rnext: "*", Pnext: 0},
+	}
+	for _, s := range good {
+		if err := CheckPos(s, lengths); err != nil {
+			t.Errorf("CheckPos(%+v) failed: %v", s, err)
+		}
+	}
+	bad := []*SAM{
+		{Rname: "chr3", Pos: 1, Cigar: "10M", Rnext: "*"},
+		{Rname: "chr1", Pos: 101, Cigar: "*", Rnext: "*"},
+		{Rname: "chr1", Pos: 92, Cigar: "10M", Rnext: "*"},
+		{Rname: "chr1", Pos: 1, Cigar: "10M", Rnext: "chr2", Pnext: 51},
+		{Rname: "chr1", Pos: 1, Cigar: "10M", Rnext: "chr3", Pnext: 1},
+		{Rname: "chr1", Pos: -1, Cigar: "*", Rnext: "*"},
+		{Rname: "chr1", Pos: 1, Cigar: "10Q", Rnext: "*"},
+	}
+	for _, s := range bad {
+		if err := CheckPos(s, lengths); err == nil {
+			t.Errorf("CheckPos(%+v) succeeded, want error", s)
+		}
+	}
+}
+
+func TestFileWithHeader(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.sam.gz")
+	input := "@SQ\tSN:chr1\tLN:100\n" +
+		"r1\t0\tchr1\t10\t60\t4M\t*\t0\t0\tACGT\tIIII\n"
+	f, err := aio.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(input)
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	h, sams, err := FileWithHeader(file)
+	if err != nil {
+		t.Fatalf("FileWithHeader(%q) failed: %v", file, err)
+	}
+	if got := h.RefLengths(); !reflect.DeepEqual(got,
+		map[string]int{"chr1": 100}) {
+		t.Fatalf("FileWithHeader(%q) lengths=%v, want chr1:100", file, got)
+	}
+	// The entries should be read from the already open file.
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for s, err := range sams {
+		if err != nil {
+			t.Fatalf("FileWithHeader(%q) failed: %v", file, err)
+		}
+		if err := CheckPos(s, h.RefLengths()); err != nil {
+			t.Fatalf("CheckPos(%v) failed: %v", s, err)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Fatalf("FileWithHeader(%q) returned %v entries, want 1", file, n)
+	}
+}
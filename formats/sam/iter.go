@@ -63,8 +63,12 @@ func Reader(r io.Reader) iter.Seq2[*SAM, error] {
 	}
 }
 
-// File iterates over SAM entries in a file.
+// File iterates over SAM entries in a file. Files with a ".bam" suffix are
+// read as BAM.
 func File(file string) iter.Seq2[*SAM, error] {
+	if isBAM(file) {
+		return fileBAM(file)
+	}
 	return func(yield func(*SAM, error) bool) {
 		f, err := aio.Open(file)
 		if err != nil {
@@ -80,8 +84,12 @@ func File(file string) iter.Seq2[*SAM, error] {
 	}
 }
 
-// FileHeader iterates over SAM or header entries in a file.
+// FileHeader iterates over SAM or header entries in a file. Files with a
+// ".bam" suffix are read as BAM.
 func FileHeader(file string) iter.Seq2[SAMOrHeader, error] {
+	if isBAM(file) {
+		return fileHeaderBAM(file)
+	}
 	return func(yield func(SAMOrHeader, error) bool) {
 		f, err := aio.Open(file)
 		if err != nil {
@@ -104,3 +112,8 @@ type SAMOrHeader struct {
 	H *string // Header line, including the '@' sign.
 	S *SAM    // SAM entry.
 }
+
+// Returns whether the file name has a BAM suffix.
+func isBAM(file string) bool {
+	return strings.HasSuffix(strings.ToLower(file), ".bam")
+}
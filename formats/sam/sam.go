@@ -1,7 +1,10 @@
-// Package sam decodes and encodes SAM files.
+// Package sam decodes and encodes SAM and BAM files.
 //
 // This package uses the format described in:
 // https://en.wikipedia.org/wiki/SAM_(file_format)
+//
+// BAM follows the SAM specification:
+// https://samtools.github.io/hts-specs/SAMv1.pdf
 package sam
 
 import (
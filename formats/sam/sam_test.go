@@ -212,3 +212,42 @@ func BenchmarkWrite(b *testing.B) {
 		sm.Write(io.Discard)
 	}
 }
+
+func TestText_arrays(t *testing.T) {
+	input := "a\t0\t*\t0\t0\t*\t*\t0\t0\t*\t*\t" +
+		"ZA:B:c,1,-2\tZB:B:C,3\tZC:B:s,-300\tZD:B:S,300,4\t" +
+		"ZE:B:i,-70000\tZF:B:I,70000\tZG:B:f,1.5,-2\n"
+	wantTags := map[string]any{
+		"ZA": []int8{1, -2},
+		"ZB": Uint8Array{3},
+		"ZC": []int16{-300},
+		"ZD": []uint16{300, 4},
+		"ZE": []int32{-70000},
+		"ZF": []uint32{70000},
+		"ZG": []float32{1.5, -2},
+	}
+
+	var sm *SAM
+	for s, err := range Reader(bytes.NewBufferString(input)) {
+		if err != nil {
+			t.Fatalf("Reader(%q) failed: %v", input, err)
+		}
+		sm = s
+	}
+	if !reflect.DeepEqual(sm.Tags, wantTags) {
+		t.Fatalf("Reader(%q).Tags=%v, want %v", input, sm.Tags, wantTags)
+	}
+	if got, err := sm.MarshalText(); err != nil || string(got) != input {
+		t.Fatalf("%v.MarshalText()=%q,%v want %q", sm, got, err, input)
+	}
+
+	for _, bad := range []string{"ZA:B:c,128", "ZA:B:C,-1", "ZA:B:x,1",
+		"ZA:B:i,a", "ZA:B:f,a"} {
+		input := "a\t0\t*\t0\t0\t*\t*\t0\t0\t*\t*\t" + bad + "\n"
+		for _, err := range Reader(bytes.NewBufferString(input)) {
+			if err == nil {
+				t.Errorf("Reader(%q) succeeded, want error", input)
+			}
+		}
+	}
+}
@@ -5,8 +5,16 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 )
 
+// Uint8Array is the value of a B-array tag of unsigned 8-bit integers (B:C).
+// It is a distinct type from []byte, which is the value of hex tags (H).
+//
+// Values of other B-array tags are []int8 (c), []int16 (s), []uint16 (S),
+// []int32 (i), []uint32 (I) and []float32 (f).
+type Uint8Array []uint8
+
 // Returns a map from tag name to its parsed (typed) value.
 func parseTags(values []string) (map[string]any, error) {
 	result := make(map[string]any, len(values)*11/10)
@@ -54,8 +62,12 @@ func parseTags(values []string) (map[string]any, error) {
 			}
 			result[parts[0]] = x
 		case "B":
-			// TODO(amit): Not implemented yet. Treating like string for now.
-			result[parts[0]] = parts[2]
+			x, err := parseArray(parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("illegal value for tag type %v: %q, "+
+					"%v", parts[1], parts[2], err)
+			}
+			result[parts[0]] = x
 		default:
 			return nil, fmt.Errorf("unrecognized tag type: %v, in tag %v",
 				parts[1], f)
@@ -110,7 +122,110 @@ func tagToText(tag string, val interface{}) string {
 		return tag + ":Z:" + val
 	case []byte:
 		return tag + ":H:" + hex.EncodeToString(val)
+	case []int8, Uint8Array, []int16, []uint16, []int32, []uint32, []float32:
+		return tag + ":B:" + arrayToText(val)
 	default:
 		panic(fmt.Sprintf("unsupported type for value %v", val))
 	}
 }
+
+// Parses the value of a B-array tag, such as "c,1,2,3".
+func parseArray(s string) (any, error) {
+	typ, s, _ := strings.Cut(s, ",")
+	var fields []string
+	if s != "" {
+		fields = strings.Split(s, ",")
+	}
+	switch typ {
+	case "c":
+		return parseIntArray[int8](fields, 8, false)
+	case "C":
+		a, err := parseIntArray[uint8](fields, 8, true)
+		return Uint8Array(a), err
+	case "s":
+		return parseIntArray[int16](fields, 16, false)
+	case "S":
+		return parseIntArray[uint16](fields, 16, true)
+	case "i":
+		return parseIntArray[int32](fields, 32, false)
+	case "I":
+		return parseIntArray[uint32](fields, 32, true)
+	case "f":
+		a := make([]float32, len(fields))
+		for i, f := range fields {
+			x, err := strconv.ParseFloat(f, 32)
+			if err != nil {
+				return nil, fmt.Errorf("want numbers")
+			}
+			a[i] = float32(x)
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("unrecognized array type: %q", typ)
+	}
+}
+
+// Parses integers of the given bit size.
+func parseIntArray[T int8 | uint8 | int16 | uint16 | int32 | uint32](
+	fields []string, bits int, unsigned bool) ([]T, error) {
+	a := make([]T, len(fields))
+	for i, f := range fields {
+		var x int64
+		var err error
+		if unsigned {
+			var u uint64
+			u, err = strconv.ParseUint(f, 10, bits)
+			x = int64(u)
+		} else {
+			x, err = strconv.ParseInt(f, 10, bits)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("want %v-bit integers", bits)
+		}
+		a[i] = T(x)
+	}
+	return a, nil
+}
+
+// Returns the SAM format representation of the value of a B-array tag,
+// without the "B:" prefix.
+func arrayToText(val any) string {
+	var typ string
+	var fields []string
+	switch val := val.(type) {
+	case []int8:
+		typ, fields = "c", intArrayToText(val)
+	case Uint8Array:
+		typ, fields = "C", intArrayToText(val)
+	case []int16:
+		typ, fields = "s", intArrayToText(val)
+	case []uint16:
+		typ, fields = "S", intArrayToText(val)
+	case []int32:
+		typ, fields = "i", intArrayToText(val)
+	case []uint32:
+		typ, fields = "I", intArrayToText(val)
+	case []float32:
+		typ = "f"
+		for _, x := range val {
+			fields = append(fields,
+				strconv.FormatFloat(float64(x), 'g', -1, 32))
+		}
+	default:
+		panic(fmt.Sprintf("unsupported array type: %T", val))
+	}
+	if len(fields) == 0 {
+		return typ
+	}
+	return typ + "," + strings.Join(fields, ",")
+}
+
+// Returns the given integers as strings.
+func intArrayToText[T int8 | uint8 | int16 | uint16 | int32 | uint32](
+	a []T) []string {
+	result := make([]string, len(a))
+	for i, x := range a {
+		result[i] = strconv.FormatInt(int64(x), 10)
+	}
+	return result
+}
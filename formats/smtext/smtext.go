@@ -15,10 +15,15 @@
 //
 // Specifically:
 //
-// Empty lines and lines beginning with '#' are ignored. Each line should have its
-// values separated by whitespaces (any kind and any amount). The first line should
-// have n letters. The others need to have n+1 values, where the first is a letter
-// and the rest are float-parseable numbers.
+// Blank lines and lines beginning with '#' (possibly after whitespace) are
+// ignored. Each line should have its values separated by whitespaces (any kind
+// and any amount). The first line should have n letters. The others need to
+// have n+1 values, where the first is a letter and the rest are float-parseable
+// numbers. The character '*' stands for align.Gap.
+//
+// Rows may have characters that are not in the first line and vice versa, as
+// in asymmetric matrices. Any character may be used, including IUPAC ambiguity
+// codes such as N, R and Y.
 package smtext
 
 import (
@@ -26,7 +31,9 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/fluhus/biostuff/align"
 )
@@ -39,7 +46,8 @@ func ReadNCBI(r io.Reader) (align.SubstitutionMatrix, error) {
 	var chars []byte
 	for sc.Scan() {
 		row := sc.Text()
-		if row == "" || row[0] == '#' {
+		if trimmed := strings.TrimSpace(row); trimmed == "" ||
+			trimmed[0] == '#' {
 			continue
 		}
 		if chars == nil { // First row
@@ -49,6 +57,10 @@ func ReadNCBI(r io.Reader) (align.SubstitutionMatrix, error) {
 				if err != nil {
 					return nil, err
 				}
+				if slices.Contains(chars, b) {
+					return nil, fmt.Errorf("duplicate character in header: %q",
+						char)
+				}
 				chars = append(chars, b)
 			}
 			continue
@@ -63,6 +75,9 @@ func ReadNCBI(r io.Reader) (align.SubstitutionMatrix, error) {
 		if err != nil {
 			return nil, err
 		}
+		if _, ok := m[[2]byte{c, chars[0]}]; ok {
+			return nil, fmt.Errorf("duplicate row: %q", valStrs[0])
+		}
 		for i, val := range valStrs[1:] {
 			x, err := strconv.ParseFloat(val, 64)
 			if err != nil {
@@ -77,6 +92,70 @@ func ReadNCBI(r io.Reader) (align.SubstitutionMatrix, error) {
 	return m, nil
 }
 
+// WriteNCBI encodes the given substitution matrix in NCBI format to w. Rows
+// are the first characters of the pairs in m, and columns are the second. All
+// combinations of a row and a column should be in m. Characters should be
+// printable, other than '#' and '*'. Characters are sorted, with align.Gap
+// written as '*' at the end.
+func WriteNCBI(w io.Writer, m align.SubstitutionMatrix) error {
+	var rows, cols []byte
+	for k := range m {
+		if !slices.Contains(rows, k[0]) {
+			rows = append(rows, k[0])
+		}
+		if !slices.Contains(cols, k[1]) {
+			cols = append(cols, k[1])
+		}
+	}
+	slices.Sort(rows)
+	slices.Sort(cols)
+	for _, c := range slices.Concat(rows, cols) {
+		if c != align.Gap && (c <= ' ' || c > '~' || c == '#' || c == '*') {
+			return fmt.Errorf("character %q cannot be written in NCBI format",
+				c)
+		}
+	}
+
+	// Format values and find the column width.
+	vals := make([][]string, len(rows))
+	width := 1
+	for i, a := range rows {
+		for _, b := range cols {
+			x, ok := m[[2]byte{a, b}]
+			if !ok {
+				return fmt.Errorf("pair (%s,%s) is not in the matrix",
+					charToText(a), charToText(b))
+			}
+			val := strconv.FormatFloat(x, 'g', -1, 64)
+			vals[i] = append(vals[i], val)
+			width = max(width, len(val))
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, " ")
+	for _, b := range cols {
+		fmt.Fprintf(bw, " %*s", width, charToText(b))
+	}
+	fmt.Fprintln(bw)
+	for i, a := range rows {
+		fmt.Fprint(bw, charToText(a))
+		for _, val := range vals[i] {
+			fmt.Fprintf(bw, " %*s", width, val)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+// Returns the textual representation of a matrix character, with * for gaps.
+func charToText(c byte) string {
+	if c == align.Gap {
+		return "*"
+	}
+	return string([]byte{c})
+}
+
 // Checks that a string is a single character and turns * into a gap.
 func extractSingleChar(s string) (byte, error) {
 	if len(s) != 1 {
@@ -1,6 +1,7 @@
 package smtext
 
 import (
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -56,3 +57,110 @@ func TestReadNCBI_bad(t *testing.T) {
 		}
 	}
 }
+
+func TestReadNCBI_lenient(t *testing.T) {
+	tests := []struct {
+		input string
+		want  align.SubstitutionMatrix
+	}{
+		{
+			// EMBOSS-style header, with IUPAC codes and a gap.
+			"#\n# This matrix was created by Todd Lowe   12/10/92\n#\n" +
+				"# Uses ambiguous nucleotide codes, probabilities rounded to\n" +
+				"#  nearest integer\n#\n" +
+				"# Lowest score = -4, Highest score = 5\n#\n" +
+				"    A   T   N   *\n" +
+				"A   5  -4  -2  -4\n" +
+				"T  -4   5  -2  -4\n" +
+				"N  -2  -2  -1  -4\r\n" +
+				"*  -4  -4  -4   1\n",
+			align.SubstitutionMatrix{
+				{'A', 'A'}: 5, {'A', 'T'}: -4, {'A', 'N'}: -2, {'A', align.Gap}: -4,
+				{'T', 'A'}: -4, {'T', 'T'}: 5, {'T', 'N'}: -2, {'T', align.Gap}: -4,
+				{'N', 'A'}: -2, {'N', 'T'}: -2, {'N', 'N'}: -1, {'N', align.Gap}: -4,
+				{align.Gap, 'A'}: -4, {align.Gap, 'T'}: -4, {align.Gap, 'N'}: -4,
+				{align.Gap, align.Gap}: 1,
+			},
+		},
+		{
+			// Asymmetric, with indented comments and blank lines.
+			"  # comment\n\n   A  C  G\n \t \nA  1 -2 -3\n  # another\n" +
+				"R  0.5 -1 0.5\n",
+			align.SubstitutionMatrix{
+				{'A', 'A'}: 1, {'A', 'C'}: -2, {'A', 'G'}: -3,
+				{'R', 'A'}: 0.5, {'R', 'C'}: -1, {'R', 'G'}: 0.5,
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := ReadNCBI(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("ReadNCBI(%q) failed: %v", test.input, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("ReadNCBI(%q)=%v, want %v",
+				test.input, got, test.want)
+		}
+	}
+}
+
+func TestReadNCBI_duplicate(t *testing.T) {
+	tests := []string{
+		" A A \nA 1 2\n",
+		" A B \nA 1 2\nA 3 4\n",
+	}
+	for _, test := range tests {
+		got, err := ReadNCBI(strings.NewReader(test))
+		if err == nil {
+			t.Fatalf("ReadNCBI(%q)=%v, want error", test, got)
+		}
+	}
+}
+
+func TestWriteNCBI(t *testing.T) {
+	m := align.SubstitutionMatrix{
+		{'A', 'A'}: 1, {'A', 'C'}: -2, {'A', align.Gap}: -1.5,
+		{'R', 'A'}: 0.5, {'R', 'C'}: -10, {'R', align.Gap}: -1,
+	}
+	want := "     A    C    *\n" +
+		"A    1   -2 -1.5\n" +
+		"R  0.5  -10   -1\n"
+	buf := &strings.Builder{}
+	if err := WriteNCBI(buf, m); err != nil {
+		t.Fatalf("WriteNCBI(%v) failed: %v", m, err)
+	}
+	if buf.String() != want {
+		t.Fatalf("WriteNCBI(%v)=%q, want %q", m, buf.String(), want)
+	}
+}
+
+func TestWriteNCBI_roundTrip(t *testing.T) {
+	for _, m := range []align.SubstitutionMatrix{
+		align.BLOSUM62, align.PAM250, align.BLOSUM45,
+	} {
+		buf := &strings.Builder{}
+		if err := WriteNCBI(buf, m); err != nil {
+			t.Fatalf("WriteNCBI(%v) failed: %v", m, err)
+		}
+		got, err := ReadNCBI(strings.NewReader(buf.String()))
+		if err != nil {
+			t.Fatalf("ReadNCBI(%q) failed: %v", buf.String(), err)
+		}
+		if !reflect.DeepEqual(got, m) {
+			t.Fatalf("ReadNCBI(WriteNCBI(%v))=%v", m, got)
+		}
+	}
+}
+
+func TestWriteNCBI_bad(t *testing.T) {
+	tests := []align.SubstitutionMatrix{
+		{{'A', 'A'}: 1, {'A', 'C'}: 1, {'C', 'A'}: 1},
+		{{' ', ' '}: 1},
+		{{'#', '#'}: 1},
+	}
+	for _, test := range tests {
+		if err := WriteNCBI(io.Discard, test); err == nil {
+			t.Errorf("WriteNCBI(%v) succeeded, want error", test)
+		}
+	}
+}
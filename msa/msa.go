@@ -0,0 +1,122 @@
+// Package msa provides progressive multiple sequence alignment.
+//
+// Sequences are aligned along a guide tree, from the leaves up. Each internal
+// node aligns the profiles (sets of aligned rows) of its children, scoring
+// columns by the average substitution score of their character pairs, as in
+// align.GlobalProfiles.
+//
+// The result is a set of gapped rows of equal length, with '-' as the gap
+// character, which can be written as aligned FASTA.
+package msa
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fluhus/biostuff/align"
+	"github.com/fluhus/biostuff/formats/newick"
+)
+
+// GapChar is the character that represents gaps in aligned rows. It is the
+// same as align.GapByte.
+const GapChar = align.GapByte
+
+// Align returns the multiple alignment of the given sequences, using a guide
+// tree from GuideTree. Returns gapped rows in the order of the input
+// sequences.
+func Align(seqs [][]byte, m align.SubstitutionMatrix) [][]byte {
+	return AlignTree(seqs, GuideTree(seqs, m), m)
+}
+
+// AlignTree returns the multiple alignment of the given sequences along the
+// given guide tree. Leaves of the tree should be named after the sequences'
+// indices ("0", "1", ...), each index appearing exactly once. Returns gapped
+// rows in the order of the input sequences.
+//
+// GapChar characters in the input sequences are treated as gaps.
+func AlignTree(seqs [][]byte, tree *newick.Node,
+	m align.SubstitutionMatrix) [][]byte {
+	if len(seqs) == 0 {
+		return nil
+	}
+	seen := make([]bool, len(seqs))
+	p := alignNode(tree, seqs, seen, m)
+	for i, ok := range seen {
+		if !ok {
+			panic(fmt.Sprintf("sequence %v is not in the tree", i))
+		}
+	}
+
+	rows := make([][]byte, len(seqs))
+	for i, row := range p.rows {
+		rows[p.idx[i]] = row
+	}
+	return rows
+}
+
+// A set of aligned rows of equal length.
+type profile struct {
+	rows [][]byte // Gapped rows
+	idx  []int    // Index of the input sequence of each row
+}
+
+// Returns the profile of the subtree under the given node.
+func alignNode(node *newick.Node, seqs [][]byte, seen []bool,
+	m align.SubstitutionMatrix) *profile {
+	if len(node.Children) == 0 {
+		i, err := strconv.Atoi(node.Name)
+		if err != nil || i < 0 || i >= len(seqs) {
+			panic(fmt.Sprintf("bad leaf name: %q, want an index between 0 "+
+				"and %v", node.Name, len(seqs)-1))
+		}
+		if seen[i] {
+			panic(fmt.Sprintf("sequence %v appears more than once in the tree",
+				i))
+		}
+		seen[i] = true
+		return &profile{[][]byte{append([]byte(nil), seqs[i]...)}, []int{i}}
+	}
+	p := alignNode(node.Children[0], seqs, seen, m)
+	for _, c := range node.Children[1:] {
+		p = merge(p, alignNode(c, seqs, seen, m), m)
+	}
+	return p
+}
+
+// Returns the alignment of the rows of a and b, using global alignment with
+// affine gaps.
+func merge(a, b *profile, m align.SubstitutionMatrix) *profile {
+	steps, _ := align.GlobalProfiles(a.rows, b.rows, m)
+	result := &profile{
+		rows: make([][]byte, len(a.rows)+len(b.rows)),
+		idx:  append(append([]int(nil), a.idx...), b.idx...),
+	}
+	for i := range result.rows {
+		result.rows[i] = make([]byte, 0, len(steps))
+	}
+	ai, bi := 0, 0
+	for _, step := range steps {
+		for i, row := range a.rows {
+			if step == align.Insertion {
+				result.rows[i] = append(result.rows[i], GapChar)
+			} else {
+				result.rows[i] = append(result.rows[i], row[ai])
+			}
+		}
+		for i, row := range b.rows {
+			j := len(a.rows) + i
+			if step == align.Deletion {
+				result.rows[j] = append(result.rows[j], GapChar)
+			} else {
+				result.rows[j] = append(result.rows[j], row[bi])
+			}
+		}
+		if step != align.Insertion {
+			ai++
+		}
+		if step != align.Deletion {
+			bi++
+		}
+	}
+	return result
+}
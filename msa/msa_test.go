@@ -0,0 +1,174 @@
+package msa
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/fluhus/biostuff/align"
+	"github.com/fluhus/biostuff/formats/newick"
+)
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		seqs []string
+		want []string
+	}{
+		{nil, nil},
+		{[]string{"ACGT"}, []string{"ACGT"}},
+		{[]string{"ACGT", "ACT", "ACGT"}, []string{"ACGT", "AC-T", "ACGT"}},
+		{
+			[]string{"GATTACA", "GATACA", "GATTTACA", "GATTACA"},
+			[]string{"GA-TTACA", "GA--TACA", "GATTTACA", "GA-TTACA"},
+		},
+	}
+	for _, test := range tests {
+		got := Align(toBytes(test.seqs), testMatrix)
+		if !reflect.DeepEqual(got, toBytes(test.want)) {
+			t.Errorf("Align(%q)=%q, want %q", test.seqs, got, test.want)
+		}
+	}
+}
+
+func TestAlign_random(t *testing.T) {
+	for range 100 {
+		seqs := make([][]byte, rand.IntN(6)+1)
+		base := randomSeq(rand.IntN(30))
+		for i := range seqs {
+			seqs[i] = mutate(base)
+		}
+		rows := Align(seqs, testMatrix)
+		if len(rows) != len(seqs) {
+			t.Fatalf("Align(%q) returned %v rows, want %v",
+				seqs, len(rows), len(seqs))
+		}
+		for i, row := range rows {
+			if len(row) != len(rows[0]) {
+				t.Fatalf("Align(%q) rows have different lengths: %q",
+					seqs, rows)
+			}
+			ungapped := bytes.ReplaceAll(row, []byte{GapChar}, nil)
+			if !bytes.Equal(ungapped, seqs[i]) {
+				t.Fatalf("Align(%q) row %v=%q, want %q without gaps",
+					seqs, i, row, seqs[i])
+			}
+		}
+		for i := range rows[0] {
+			allGaps := true
+			for _, row := range rows {
+				allGaps = allGaps && row[i] == GapChar
+			}
+			if allGaps {
+				t.Fatalf("Align(%q) has an all-gap column: %q", seqs, rows)
+			}
+		}
+	}
+}
+
+// Pairwise alignment should be as good as align.Global.
+func TestAlign_pair(t *testing.T) {
+	for range 100 {
+		a, b := randomSeq(rand.IntN(20)), randomSeq(rand.IntN(20))
+		_, want := align.Global(a, b, testMatrix)
+		rows := Align([][]byte{a, b}, testMatrix)
+		if got := rowsScore(rows[0], rows[1], testMatrix); got != want {
+			t.Fatalf("Align(%q,%q)=%q with score %v, want %v",
+				a, b, rows, got, want)
+		}
+	}
+}
+
+func TestAlignTree_bad(t *testing.T) {
+	seqs := toBytes([]string{"A", "C"})
+	trees := []*newick.Node{
+		{Name: "0"},
+		{Children: []*newick.Node{{Name: "0"}, {Name: "0"}}},
+		{Children: []*newick.Node{{Name: "0"}, {Name: "2"}}},
+		{Children: []*newick.Node{{Name: "0"}, {Name: "x"}}},
+	}
+	for _, tree := range trees {
+		func() {
+			defer func() {
+				if recover() == nil {
+					txt, _ := tree.MarshalText()
+					t.Errorf("AlignTree(%q) did not panic", txt)
+				}
+			}()
+			AlignTree(seqs, tree, testMatrix)
+		}()
+	}
+}
+
+func toBytes(s []string) [][]byte {
+	if s == nil {
+		return nil
+	}
+	result := make([][]byte, len(s))
+	for i := range s {
+		result[i] = []byte(s[i])
+	}
+	return result
+}
+
+func randomSeq(n int) []byte {
+	seq := make([]byte, n)
+	for i := range seq {
+		seq[i] = "ACGT"[rand.IntN(4)]
+	}
+	return seq
+}
+
+// Returns a copy of seq with random substitutions, insertions and deletions.
+func mutate(seq []byte) []byte {
+	var result []byte
+	for _, c := range seq {
+		switch rand.IntN(10) {
+		case 0:
+			result = append(result, "ACGT"[rand.IntN(4)])
+		case 1:
+		case 2:
+			result = append(result, c, "ACGT"[rand.IntN(4)])
+		default:
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// Returns the score of two aligned rows.
+func rowsScore(a, b []byte, m align.SubstitutionMatrix) float64 {
+	var steps []align.Step
+	var ua, ub []byte
+	for i := range a {
+		switch {
+		case a[i] == GapChar:
+			steps = append(steps, align.Insertion)
+			ub = append(ub, b[i])
+		case b[i] == GapChar:
+			steps = append(steps, align.Deletion)
+			ua = append(ua, a[i])
+		default:
+			steps = append(steps, align.Match)
+			ua, ub = append(ua, a[i]), append(ub, b[i])
+		}
+	}
+	score := 0.0
+	for i, step := range steps {
+		if step != align.Match && (i == 0 || steps[i-1] != step) {
+			score += m[[2]byte{align.Gap, align.Gap}]
+		}
+		switch step {
+		case align.Match:
+			score += m[[2]byte{ua[0], ub[0]}]
+			ua, ub = ua[1:], ub[1:]
+		case align.Deletion:
+			score += m[[2]byte{ua[0], align.Gap}]
+			ua = ua[1:]
+		case align.Insertion:
+			score += m[[2]byte{align.Gap, ub[0]}]
+			ub = ub[1:]
+		}
+	}
+	return score
+}
@@ -0,0 +1,115 @@
+// Guide tree construction.
+
+package msa
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fluhus/biostuff/align"
+	"github.com/fluhus/biostuff/formats/newick"
+)
+
+// Distances returns the pairwise distances between the given sequences, where
+// the distance between two sequences is the fraction of non-identity columns
+// in their global alignment with m. The result is symmetrical, with zeros on
+// the diagonal.
+func Distances(seqs [][]byte, m align.SubstitutionMatrix) [][]float64 {
+	d := make([][]float64, len(seqs))
+	for i := range d {
+		d[i] = make([]float64, len(seqs))
+	}
+	for i := range seqs {
+		for j := range i {
+			steps, _ := align.Global(seqs[i], seqs[j], m)
+			if len(steps) > 0 {
+				d[i][j] = 1 - align.Summarize(seqs[i], seqs[j], steps, m).Identity()
+			}
+			d[j][i] = d[i][j]
+		}
+	}
+	return d
+}
+
+// UPGMA returns a rooted binary tree of the given distances, using the
+// unweighted pair group method with arithmetic mean. Leaves are named after the
+// given names, and branch lengths are half the distances at which clusters
+// merge. dist should be a symmetrical square matrix with the same length as
+// names. Returns nil if names is empty.
+func UPGMA(dist [][]float64, names []string) *newick.Node {
+	if len(dist) != len(names) {
+		panic(fmt.Sprintf("lengths of dist and names don't match: %v!=%v",
+			len(dist), len(names)))
+	}
+	for i := range dist {
+		if len(dist[i]) != len(dist) {
+			panic(fmt.Sprintf("bad length of row %v: %v, want %v",
+				i, len(dist[i]), len(dist)))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	// Active clusters and their distances.
+	type cluster struct {
+		node   *newick.Node
+		size   int
+		height float64
+	}
+	clusters := make([]*cluster, len(names))
+	for i, name := range names {
+		clusters[i] = &cluster{&newick.Node{Name: name}, 1, 0}
+	}
+	d := make([][]float64, len(dist))
+	for i := range d {
+		d[i] = append([]float64(nil), dist[i]...)
+	}
+
+	for len(clusters) > 1 {
+		bi, bj := 0, 1
+		for i := range clusters {
+			for j := i + 1; j < len(clusters); j++ {
+				if d[i][j] < d[bi][bj] {
+					bi, bj = i, j
+				}
+			}
+		}
+
+		ci, cj := clusters[bi], clusters[bj]
+		height := d[bi][bj] / 2
+		ci.node.Distance = height - ci.height
+		cj.node.Distance = height - cj.height
+		merged := &cluster{
+			&newick.Node{Children: []*newick.Node{ci.node, cj.node}},
+			ci.size + cj.size, height,
+		}
+
+		// Update distances, putting the merged cluster in place of bi and
+		// removing bj.
+		for k := range clusters {
+			x := (float64(ci.size)*d[bi][k] + float64(cj.size)*d[bj][k]) /
+				float64(merged.size)
+			d[bi][k], d[k][bi] = x, x
+		}
+		d[bi][bi] = 0
+		clusters[bi] = merged
+		clusters = append(clusters[:bj], clusters[bj+1:]...)
+		d = append(d[:bj], d[bj+1:]...)
+		for k := range d {
+			d[k] = append(d[k][:bj], d[k][bj+1:]...)
+		}
+	}
+	return clusters[0].node
+}
+
+// GuideTree returns a UPGMA tree of the distances between the given sequences,
+// for use with AlignTree. Leaves are named after the sequences' indices ("0",
+// "1", ...).
+func GuideTree(seqs [][]byte, m align.SubstitutionMatrix) *newick.Node {
+	names := make([]string, len(seqs))
+	for i := range names {
+		names[i] = strconv.Itoa(i)
+	}
+	return UPGMA(Distances(seqs, m), names)
+}
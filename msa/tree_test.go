@@ -0,0 +1,70 @@
+package msa
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fluhus/biostuff/align"
+)
+
+func TestUPGMA(t *testing.T) {
+	tests := []struct {
+		dist  [][]float64
+		names []string
+		want  string
+	}{
+		{[][]float64{{0}}, []string{"a"}, "a;"},
+		{[][]float64{
+			{0, 2, 4},
+			{2, 0, 4},
+			{4, 4, 0},
+		}, []string{"a", "b", "c"}, "((a:1,b:1):1,c:2);"},
+		{[][]float64{
+			{0, 6, 2, 6},
+			{6, 0, 6, 4},
+			{2, 6, 0, 6},
+			{6, 4, 6, 0},
+		}, []string{"a", "b", "c", "d"}, "((a:1,c:1):2,(b:2,d:2):1);"},
+	}
+	for _, test := range tests {
+		got, err := UPGMA(test.dist, test.names).MarshalText()
+		if err != nil {
+			t.Fatalf("UPGMA(%v).MarshalText() failed: %v", test.dist, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("UPGMA(%v)=%q, want %q", test.dist, got, test.want)
+		}
+	}
+}
+
+func TestUPGMA_empty(t *testing.T) {
+	if got := UPGMA(nil, nil); got != nil {
+		t.Errorf("UPGMA(nil)=%v, want nil", got)
+	}
+}
+
+func TestDistances(t *testing.T) {
+	seqs := [][]byte{[]byte("ACGT"), []byte("ACGT"), []byte("ACCA")}
+	want := [][]float64{
+		{0, 0, 0.5},
+		{0, 0, 0.5},
+		{0.5, 0.5, 0},
+	}
+	if got := Distances(seqs, testMatrix); !reflect.DeepEqual(got, want) {
+		t.Errorf("Distances(%q)=%v, want %v", seqs, got, want)
+	}
+}
+
+var testMatrix = func() align.SubstitutionMatrix {
+	m := align.SubstitutionMatrix{}
+	for _, a := range []byte("ACGT") {
+		for _, b := range []byte("ACGT") {
+			if a == b {
+				m[[2]byte{a, b}] = 2
+			} else {
+				m[[2]byte{a, b}] = -1
+			}
+		}
+	}
+	return m.WithGaps(-2, -1)
+}()